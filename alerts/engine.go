@@ -0,0 +1,155 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package alerts evaluates user-defined Rules against a sensorstore.Store
+// and dispatches Events to pluggable Notifiers, e.g. "humidity > 80% for
+// 3 consecutive samples" notified by SMTP, webhook or MQTT.
+package alerts
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+)
+
+// Engine evaluates a set of Rules against a Store and notifies a set of
+// Notifiers when one starts or stops firing.
+type Engine struct {
+	store     *sensorstore.Store
+	rules     []Rule
+	notifiers []Notifier
+	state     *stateStore
+
+	wg sync.WaitGroup
+}
+
+// New creates an Engine evaluating rules against store, persisting alert
+// state in its own bbolt database at statePath, and notifying the
+// Notifier described by every URL in notifierURLs (e.g.
+// "webhook://example.com/hook"). The package implementing a given
+// notifier URL scheme must have been imported, typically blank-imported,
+// so that its init function has registered itself via RegisterNotifier.
+func New(store *sensorstore.Store, statePath string, rules []Rule, notifierURLs []string) (*Engine, error) {
+
+	state, err := openStateStore(statePath)
+	if err != nil {
+		return nil, err
+	}
+
+	notifiers := make([]Notifier, 0, len(notifierURLs))
+	for _, rawURL := range notifierURLs {
+
+		notifier, err := openNotifier(rawURL)
+		if err != nil {
+			state.Close()
+			return nil, err
+		}
+
+		notifiers = append(notifiers, notifier)
+	}
+
+	return &Engine{
+		store:     store,
+		rules:     rules,
+		notifiers: notifiers,
+		state:     state,
+	}, nil
+}
+
+// Start evaluates every Rule against newly stored values until ctx is
+// cancelled.
+func (e *Engine) Start(ctx context.Context) {
+	for _, rule := range e.rules {
+		e.wg.Add(1)
+		go e.runRule(ctx, rule)
+	}
+}
+
+// Close waits for every Rule goroutine started by Start to exit, then
+// releases the Engine's alert state database. It does not close the
+// Store, which the caller retains ownership of, nor the Notifiers opened
+// by New, since Notifier has no Close method.
+func (e *Engine) Close() error {
+	e.wg.Wait()
+	return e.state.Close()
+}
+
+func (e *Engine) runRule(ctx context.Context, rule Rule) {
+
+	defer e.wg.Done()
+
+	c, cancel := e.store.Subscribe(rule.SensorID)
+	defer cancel()
+
+	for {
+		select {
+		case values, open := <-c:
+
+			if !open {
+				return
+			}
+
+			if err := e.evaluate(rule, values); err != nil {
+				log.Println("Failed to evaluate alert rule", rule.ID, err)
+			}
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (e *Engine) evaluate(rule Rule, values sensorstore.TimedSensorValues) error {
+
+	state, err := e.state.load(rule.ID)
+	if err != nil {
+		return err
+	}
+
+	value := rule.metricValue(values.Values)
+
+	if !rule.breaches(value) {
+
+		state.Consecutive = 0
+
+		if state.Firing {
+			state.Firing = false
+			e.notify(Event{Rule: rule, Value: value, Firing: false, Timestamp: values.Timestamp})
+		}
+
+		return e.state.save(rule.ID, state)
+	}
+
+	state.Consecutive++
+
+	if !state.Firing && state.Consecutive >= rule.Consecutive && time.Since(state.LastFired) >= rule.Cooldown {
+		state.Firing = true
+		state.LastFired = values.Timestamp
+		e.notify(Event{Rule: rule, Value: value, Firing: true, Timestamp: values.Timestamp})
+	}
+
+	return e.state.save(rule.ID, state)
+}
+
+func (e *Engine) notify(event Event) {
+	for _, notifier := range e.notifiers {
+		if err := notifier.Notify(event); err != nil {
+			log.Println("Notifier failed for rule", event.Rule.ID, ":", err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package alerts
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+)
+
+// newTestEngine returns an Engine with no Notifiers, persisting state in
+// a fresh bbolt database under t.TempDir(), ready to have evaluate
+// called against it directly without a Store or background goroutines.
+func newTestEngine(t *testing.T) *Engine {
+	t.Helper()
+
+	state, err := openStateStore(filepath.Join(t.TempDir(), "alerts.db"))
+	if err != nil {
+		t.Fatalf("openStateStore: %s", err)
+	}
+	t.Cleanup(func() { state.Close() })
+
+	return &Engine{state: state}
+}
+
+func humidityRule(max float32, consecutive int, cooldown time.Duration) Rule {
+	return Rule{
+		ID:          "humidity-too-high",
+		SensorID:    "cellar-top",
+		Metric:      MetricHumidity,
+		Max:         float32p(max),
+		Consecutive: consecutive,
+		Cooldown:    cooldown,
+	}
+}
+
+func valuesAt(t time.Time, humidity float32) sensorstore.TimedSensorValues {
+	return sensorstore.TimedSensorValues{Timestamp: t, Values: sensorstore.SensorValues{Humidity: humidity}}
+}
+
+func TestEngineEvaluateFiresAfterConsecutiveBreaches(t *testing.T) {
+
+	e := newTestEngine(t)
+	rule := humidityRule(80, 3, time.Hour)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := e.evaluate(rule, valuesAt(now, 90)); err != nil {
+			t.Fatalf("evaluate: %s", err)
+		}
+
+		state, err := e.state.load(rule.ID)
+		if err != nil {
+			t.Fatalf("load: %s", err)
+		}
+		if state.Firing {
+			t.Fatalf("rule fired after only %d breaches, want 3", i+1)
+		}
+	}
+
+	if err := e.evaluate(rule, valuesAt(now, 90)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+
+	state, err := e.state.load(rule.ID)
+	if err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	if !state.Firing {
+		t.Fatal("rule did not fire after 3 consecutive breaches")
+	}
+}
+
+func TestEngineEvaluateResetsConsecutiveOnRecovery(t *testing.T) {
+
+	e := newTestEngine(t)
+	rule := humidityRule(80, 3, time.Hour)
+	now := time.Now()
+
+	for i := 0; i < 2; i++ {
+		if err := e.evaluate(rule, valuesAt(now, 90)); err != nil {
+			t.Fatalf("evaluate: %s", err)
+		}
+	}
+
+	if err := e.evaluate(rule, valuesAt(now, 50)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+
+	state, err := e.state.load(rule.ID)
+	if err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	if state.Consecutive != 0 {
+		t.Fatalf("Consecutive = %d after a non-breaching value, want 0", state.Consecutive)
+	}
+}
+
+func TestEngineEvaluateStopsFiringOnRecovery(t *testing.T) {
+
+	e := newTestEngine(t)
+	rule := humidityRule(80, 1, time.Hour)
+	now := time.Now()
+
+	if err := e.evaluate(rule, valuesAt(now, 90)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+
+	state, err := e.state.load(rule.ID)
+	if err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	if !state.Firing {
+		t.Fatal("rule did not fire on the first breach with Consecutive: 1")
+	}
+
+	if err := e.evaluate(rule, valuesAt(now.Add(time.Minute), 50)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+
+	state, err = e.state.load(rule.ID)
+	if err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	if state.Firing {
+		t.Fatal("rule kept firing after a non-breaching value")
+	}
+}
+
+func TestEngineEvaluateHonoursCooldown(t *testing.T) {
+
+	e := newTestEngine(t)
+	rule := humidityRule(80, 1, time.Hour)
+	now := time.Now()
+
+	if err := e.evaluate(rule, valuesAt(now, 90)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+	if err := e.evaluate(rule, valuesAt(now.Add(time.Minute), 50)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+
+	// Breaches again a minute later, well within the 1h cooldown since it
+	// last fired.
+	if err := e.evaluate(rule, valuesAt(now.Add(2*time.Minute), 90)); err != nil {
+		t.Fatalf("evaluate: %s", err)
+	}
+
+	state, err := e.state.load(rule.ID)
+	if err != nil {
+		t.Fatalf("load: %s", err)
+	}
+	if state.Firing {
+		t.Fatal("rule re-fired before its cooldown elapsed")
+	}
+}
@@ -0,0 +1,80 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package alerts
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Event describes a Rule transitioning between firing and resolved.
+type Event struct {
+	Rule      Rule
+	Value     float32
+	Firing    bool
+	Timestamp time.Time
+}
+
+// String renders the Event the way the built-in notifiers report it,
+// e.g. "ALERT cellar-top humidity: 83.10 outside bounds (rule
+// high-humidity)", or with "RESOLVED" in place of "ALERT" once the Rule
+// stops firing.
+func (e Event) String() string {
+	state := "ALERT"
+	if !e.Firing {
+		state = "RESOLVED"
+	}
+	return fmt.Sprintf("%s %s %s: %.2f outside bounds (rule %s)", state, e.Rule.SensorID, e.Rule.Metric, e.Value, e.Rule.ID)
+}
+
+// Notifier dispatches Events somewhere a human, or another system, can
+// see them. Implementations live in their own alerts/notifier/<name>
+// package, e.g. alerts/notifier/smtp or alerts/notifier/webhook, and
+// register themselves with RegisterNotifier from an init function so
+// users can also register their own.
+type Notifier interface {
+	Notify(event Event) error
+}
+
+// OpenNotifierFunc opens the Notifier described by a notifier URL, e.g.
+// "webhook://example.com/hook" or "mqtt://broker:1883/cellarsense/alerts".
+type OpenNotifierFunc func(notifierURL *url.URL) (Notifier, error)
+
+var notifierTypes = make(map[string]OpenNotifierFunc)
+
+// RegisterNotifier makes a Notifier available under the given notifier
+// URL scheme. It is meant to be called from the init function of an
+// alerts/notifier/<name> package that New can then dispatch to, once
+// that package has been imported (typically blank-imported) by main.
+func RegisterNotifier(scheme string, open OpenNotifierFunc) {
+	notifierTypes[scheme] = open
+}
+
+func openNotifier(rawURL string) (Notifier, error) {
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse notifier URL %q: %s", rawURL, err)
+	}
+
+	open, ok := notifierTypes[parsed.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unknown notifier %q, is its package imported?", parsed.Scheme)
+	}
+
+	return open(parsed)
+}
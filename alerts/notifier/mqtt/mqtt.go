@@ -0,0 +1,119 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package mqtt is the alerts.Notifier implementation backing the
+// "mqtt://" notifier URL scheme. It publishes every Event as JSON to the
+// broker and topic in the URL, with an optional QoS and retained flag.
+package mqtt
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/alerts"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const connectTimeout = 10 * time.Second
+
+func init() {
+	alerts.RegisterNotifier("mqtt", open)
+}
+
+// Notifier publishes every Event as JSON to an MQTT topic.
+type Notifier struct {
+	client   paho.Client
+	topic    string
+	qos      byte
+	retained bool
+}
+
+// payload is the JSON message published for every Event.
+type payload struct {
+	RuleID    string    `json:"rule_id"`
+	SensorID  string    `json:"sensor_id"`
+	Metric    string    `json:"metric"`
+	Value     float32   `json:"value"`
+	Firing    bool      `json:"firing"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// open parses a URL of the form
+// "mqtt://broker:1883/cellarsense/alerts?qos=1&retained=true" into a
+// Notifier connected to broker, publishing to the path as its topic.
+func open(notifierURL *url.URL) (alerts.Notifier, error) {
+
+	topic := strings.TrimPrefix(notifierURL.Path, "/")
+	if topic == "" {
+		return nil, fmt.Errorf("mqtt notifier URL must include a topic, e.g. mqtt://broker:1883/cellarsense/alerts")
+	}
+
+	query := notifierURL.Query()
+
+	var qos byte
+	if raw := query.Get("qos"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > 2 {
+			return nil, fmt.Errorf("invalid mqtt qos %q, must be 0, 1 or 2", raw)
+		}
+		qos = byte(parsed)
+	}
+
+	retained := query.Get("retained") == "true"
+
+	opts := paho.NewClientOptions().AddBroker(fmt.Sprintf("tcp://%s", notifierURL.Host))
+	if notifierURL.User != nil {
+		opts.SetUsername(notifierURL.User.Username())
+		if password, ok := notifierURL.User.Password(); ok {
+			opts.SetPassword(password)
+		}
+	}
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return nil, fmt.Errorf("timed out connecting to mqtt broker %s", notifierURL.Host)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker %s: %s", notifierURL.Host, err)
+	}
+
+	return &Notifier{client: client, topic: topic, qos: qos, retained: retained}, nil
+}
+
+func (n *Notifier) Notify(event alerts.Event) error {
+
+	body, err := json.Marshal(payload{
+		RuleID:    event.Rule.ID,
+		SensorID:  event.Rule.SensorID,
+		Metric:    string(event.Rule.Metric),
+		Value:     event.Value,
+		Firing:    event.Firing,
+		Timestamp: event.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	token := n.client.Publish(n.topic, n.qos, n.retained, body)
+	token.Wait()
+
+	return token.Error()
+}
@@ -0,0 +1,97 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package webhook is the alerts.Notifier implementation backing the
+// "webhook://" and "webhooks://" notifier URL schemes. It POSTs every
+// Event as JSON to the URL, with its scheme replaced by "http" or
+// "https" respectively.
+package webhook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/alerts"
+)
+
+const requestTimeout = 10 * time.Second
+
+func init() {
+	alerts.RegisterNotifier("webhook", open("http"))
+	alerts.RegisterNotifier("webhooks", open("https"))
+}
+
+// Notifier POSTs every Event as JSON to URL.
+type Notifier struct {
+	client *http.Client
+	url    string
+}
+
+// payload is the JSON body POSTed for every Event.
+type payload struct {
+	RuleID    string    `json:"rule_id"`
+	SensorID  string    `json:"sensor_id"`
+	Metric    string    `json:"metric"`
+	Value     float32   `json:"value"`
+	Firing    bool      `json:"firing"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// open returns an OpenNotifierFunc that rewrites the notifier URL's
+// scheme to httpScheme before building the Notifier, so "webhook://" and
+// "webhooks://" share one implementation.
+func open(httpScheme string) alerts.OpenNotifierFunc {
+	return func(notifierURL *url.URL) (alerts.Notifier, error) {
+
+		target := *notifierURL
+		target.Scheme = httpScheme
+
+		return &Notifier{
+			client: &http.Client{Timeout: requestTimeout},
+			url:    target.String(),
+		}, nil
+	}
+}
+
+func (n *Notifier) Notify(event alerts.Event) error {
+
+	body, err := json.Marshal(payload{
+		RuleID:    event.Rule.ID,
+		SensorID:  event.Rule.SensorID,
+		Metric:    string(event.Rule.Metric),
+		Value:     event.Value,
+		Firing:    event.Firing,
+		Timestamp: event.Timestamp,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := n.client.Post(n.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook %s returned status %s", n.url, resp.Status)
+	}
+
+	return nil
+}
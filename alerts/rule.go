@@ -0,0 +1,66 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package alerts
+
+import (
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+)
+
+// Metric identifies which measurement of a sensor's SensorValues a Rule
+// evaluates.
+type Metric string
+
+const (
+	MetricTemperature Metric = "temperature"
+	MetricHumidity    Metric = "humidity"
+)
+
+// Rule is a user-defined condition evaluated against every value stored
+// for SensorID: it fires once Metric has been outside [Min, Max] for
+// Consecutive samples in a row, e.g. "humidity > 80% for 3 consecutive
+// samples" is Metric: MetricHumidity, Max: 80, Consecutive: 3. Either
+// bound may be left nil to only check the other one. Once firing, the
+// Rule will not fire again until Cooldown has elapsed since it last did,
+// so a flapping sensor doesn't spam notifiers.
+type Rule struct {
+	ID          string
+	SensorID    string
+	Metric      Metric
+	Min, Max    *float32
+	Consecutive int
+	Cooldown    time.Duration
+}
+
+// breaches reports whether value falls outside the Rule's bounds.
+func (r Rule) breaches(value float32) bool {
+	if r.Min != nil && value < *r.Min {
+		return true
+	}
+	if r.Max != nil && value > *r.Max {
+		return true
+	}
+	return false
+}
+
+// metricValue extracts the measurement the Rule cares about from values.
+func (r Rule) metricValue(values sensorstore.SensorValues) float32 {
+	if r.Metric == MetricHumidity {
+		return values.Humidity
+	}
+	return values.Temperature
+}
@@ -0,0 +1,66 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package alerts
+
+import (
+	"testing"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+)
+
+func float32p(v float32) *float32 { return &v }
+
+func TestRuleBreaches(t *testing.T) {
+
+	cases := []struct {
+		name  string
+		rule  Rule
+		value float32
+		want  bool
+	}{
+		{"within bounds", Rule{Min: float32p(10), Max: float32p(16)}, 12, false},
+		{"below min", Rule{Min: float32p(10), Max: float32p(16)}, 9.9, true},
+		{"above max", Rule{Min: float32p(10), Max: float32p(16)}, 16.1, true},
+		{"at min is not a breach", Rule{Min: float32p(10)}, 10, false},
+		{"at max is not a breach", Rule{Max: float32p(16)}, 16, false},
+		{"no bounds never breaches", Rule{}, 1000, false},
+		{"only max set, below it", Rule{Max: float32p(80)}, 50, false},
+		{"only max set, above it", Rule{Max: float32p(80)}, 81, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.rule.breaches(c.value); got != c.want {
+				t.Errorf("Rule%+v.breaches(%v) = %v, want %v", c.rule, c.value, got, c.want)
+			}
+		})
+	}
+}
+
+func TestRuleMetricValue(t *testing.T) {
+
+	values := sensorstore.SensorValues{Temperature: 18, Humidity: 42}
+
+	temperatureRule := Rule{Metric: MetricTemperature}
+	if got := temperatureRule.metricValue(values); got != 18 {
+		t.Errorf("metricValue(MetricTemperature) = %v, want 18", got)
+	}
+
+	humidityRule := Rule{Metric: MetricHumidity}
+	if got := humidityRule.metricValue(values); got != 42 {
+		t.Errorf("metricValue(MetricHumidity) = %v, want 42", got)
+	}
+}
@@ -0,0 +1,94 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package alerts
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+)
+
+const stateBucket = "alerts"
+
+// ruleState is the per-Rule bookkeeping the Engine persists across
+// restarts, keyed by Rule.ID.
+type ruleState struct {
+	Consecutive int
+	Firing      bool
+	LastFired   time.Time
+}
+
+// stateStore persists ruleState in its own bbolt database, independent
+// of whichever sensorstore.Backend the daemon is configured with, so a
+// restart doesn't forget that a Rule was already firing and re-notify.
+type stateStore struct {
+	db *bbolt.DB
+}
+
+func openStateStore(path string) (*stateStore, error) {
+
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open alert state %s: %s", path, err)
+	}
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(stateBucket))
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &stateStore{db: db}, nil
+}
+
+func (s *stateStore) load(ruleID string) (ruleState, error) {
+
+	var state ruleState
+
+	err := s.db.View(func(tx *bbolt.Tx) error {
+
+		value := tx.Bucket([]byte(stateBucket)).Get([]byte(ruleID))
+		if value == nil {
+			return nil
+		}
+
+		return json.Unmarshal(value, &state)
+	})
+
+	return state, err
+}
+
+func (s *stateStore) save(ruleID string, state ruleState) error {
+
+	return s.db.Update(func(tx *bbolt.Tx) error {
+
+		value, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+
+		return tx.Bucket([]byte(stateBucket)).Put([]byte(ruleID), value)
+	})
+}
+
+func (s *stateStore) Close() error {
+	return s.db.Close()
+}
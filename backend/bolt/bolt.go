@@ -0,0 +1,351 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package bolt is the sensorstore.Backend implementation backing the
+// "bolt://" URL scheme. It stores every sensor in its own bbolt bucket,
+// holding a "raw", "hourly" and "daily" sibling bucket each keyed by the
+// sample's timestamp truncated to the corresponding resolution.
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	bbolt "go.etcd.io/bbolt"
+)
+
+const (
+	sensorBucket = "sensors"
+	rawBucket    = "raw"
+	timeFormat   = time.RFC3339
+)
+
+// resolutionBucket returns the name of the sibling bucket a resolution's
+// values are stored in, e.g. "sensors/<id>/hourly".
+func resolutionBucket(resolution sensorstore.Resolution) string {
+	if resolution == sensorstore.ResolutionRaw {
+		return rawBucket
+	}
+	return string(resolution)
+}
+
+func init() {
+	sensorstore.RegisterBackend("bolt", open)
+}
+
+// Backend stores sensor values in a bbolt database, one bucket per
+// sensor, keyed by the sample's truncated timestamp.
+type Backend struct {
+	db         *bbolt.DB
+	resolution time.Duration
+}
+
+func open(backendURL *url.URL, resolution time.Duration) (sensorstore.Backend, error) {
+
+	path := backendURL.Path
+	if path == "" {
+		path = backendURL.Host
+	}
+
+	log.Println("Opening Database", path)
+	db, err := bbolt.Open(path, 0600, &bbolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("Initialize Sensor Store with bucket", sensorBucket)
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(sensorBucket))
+		if err != nil {
+			return fmt.Errorf("failed to create bucket %s: %s", sensorBucket, err)
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &Backend{db: db, resolution: resolution}, nil
+}
+
+func (b *Backend) StoreValues(sensorID string, timestamp time.Time, values sensorstore.SensorValues) error {
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+
+		raw, err := b.rawBucket(tx, sensorID)
+		if err != nil {
+			return err
+		}
+
+		key := []byte(timestamp.Truncate(b.resolution).UTC().Format(timeFormat))
+
+		value, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+
+		return raw.Put(key, value)
+	})
+}
+
+func (b *Backend) ReadLastValue(sensorID string) (*sensorstore.TimedSensorValues, error) {
+
+	var lastValue *sensorstore.TimedSensorValues
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+
+		raw := b.existingBucket(tx, sensorID, rawBucket)
+		if raw == nil {
+			return nil
+		}
+
+		k, v := raw.Cursor().Last()
+		if k == nil {
+			return nil
+		}
+
+		result, err := convertToValues(k, v)
+		lastValue = result
+
+		return err
+	})
+
+	return lastValue, err
+}
+
+func (b *Backend) ReadValues(sensorID string, duration time.Duration) ([]*sensorstore.TimedSensorValues, error) {
+
+	now := time.Now()
+
+	endTime := now.Truncate(b.resolution)
+	var start []byte
+
+	if duration > 0 {
+		start = []byte(now.Add(-duration).Truncate(b.resolution).UTC().Format(timeFormat))
+	} else {
+		start = []byte(now.Add(duration).Truncate(b.resolution).UTC().Format(timeFormat))
+	}
+
+	valuesList := []*sensorstore.TimedSensorValues{}
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+
+		raw := b.existingBucket(tx, sensorID, rawBucket)
+		if raw == nil {
+			return nil
+		}
+
+		c := raw.Cursor()
+
+		for k, v := c.Seek(start); len(k) != 0; k, v = c.Next() {
+
+			currentKey, err := time.Parse(timeFormat, string(k))
+			if err != nil {
+				return err
+			}
+
+			if currentKey.After(endTime) {
+				break
+			}
+
+			values, err := convertToValues(k, v)
+			if err != nil {
+				return err
+			}
+
+			valuesList = append(valuesList, values)
+		}
+
+		return nil
+	})
+
+	return valuesList, err
+}
+
+// StoreAggregated persists a rolled-up aggregate in the sibling bucket
+// for resolution, e.g. "sensors/<id>/hourly".
+func (b *Backend) StoreAggregated(sensorID string, resolution sensorstore.Resolution, timestamp time.Time, values sensorstore.AggregatedSensorValues) error {
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+
+		bucket, err := b.bucket(tx, sensorID, resolutionBucket(resolution))
+		if err != nil {
+			return err
+		}
+
+		key := []byte(timestamp.UTC().Format(timeFormat))
+
+		value, err := json.Marshal(values)
+		if err != nil {
+			return err
+		}
+
+		return bucket.Put(key, value)
+	})
+}
+
+// ReadAggregated returns every aggregate stored for sensorID at the
+// given resolution within duration of now, oldest first.
+func (b *Backend) ReadAggregated(sensorID string, resolution sensorstore.Resolution, duration time.Duration) ([]*sensorstore.TimedAggregatedSensorValues, error) {
+
+	now := time.Now()
+
+	var start []byte
+	if duration > 0 {
+		start = []byte(now.Add(-duration).UTC().Format(timeFormat))
+	} else {
+		start = []byte(now.Add(duration).UTC().Format(timeFormat))
+	}
+
+	valuesList := []*sensorstore.TimedAggregatedSensorValues{}
+
+	err := b.db.View(func(tx *bbolt.Tx) error {
+
+		bucket := b.existingBucket(tx, sensorID, resolutionBucket(resolution))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+
+		for k, v := c.Seek(start); len(k) != 0; k, v = c.Next() {
+
+			timestamp, err := time.Parse(timeFormat, string(k))
+			if err != nil {
+				return err
+			}
+
+			if timestamp.After(now) {
+				break
+			}
+
+			var values sensorstore.AggregatedSensorValues
+			if err := json.Unmarshal(v, &values); err != nil {
+				return err
+			}
+
+			valuesList = append(valuesList, &sensorstore.TimedAggregatedSensorValues{Timestamp: timestamp, Values: values})
+		}
+
+		return nil
+	})
+
+	return valuesList, err
+}
+
+// DeleteBefore removes every value stored for sensorID at the given
+// resolution older than before.
+func (b *Backend) DeleteBefore(sensorID string, resolution sensorstore.Resolution, before time.Time) error {
+
+	return b.db.Update(func(tx *bbolt.Tx) error {
+
+		bucket := b.existingBucket(tx, sensorID, resolutionBucket(resolution))
+		if bucket == nil {
+			return nil
+		}
+
+		c := bucket.Cursor()
+		cutoff := before.UTC().Format(timeFormat)
+
+		for k, _ := c.First(); len(k) != 0 && string(k) < cutoff; k, _ = c.Next() {
+			if err := c.Delete(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// bucket returns the named sibling bucket of sensorID, e.g. "raw" or
+// "hourly", creating the sensor's bucket and the sibling itself if
+// either does not yet exist.
+func (b *Backend) bucket(tx *bbolt.Tx, sensorID, name string) (*bbolt.Bucket, error) {
+
+	root := tx.Bucket([]byte(sensorBucket))
+
+	sensor, err := root.CreateBucketIfNotExists([]byte(sensorID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket %s: %s", sensorID, err)
+	}
+
+	bucket, err := sensor.CreateBucketIfNotExists([]byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create bucket %s/%s: %s", sensorID, name, err)
+	}
+
+	return bucket, nil
+}
+
+// rawBucket is a StoreValues convenience wrapper around bucket for the
+// "raw" sibling.
+func (b *Backend) rawBucket(tx *bbolt.Tx, sensorID string) (*bbolt.Bucket, error) {
+	return b.bucket(tx, sensorID, rawBucket)
+}
+
+// existingBucket returns the named sibling bucket of sensorID, or nil if
+// the sensor or the sibling bucket itself has never been written to.
+func (b *Backend) existingBucket(tx *bbolt.Tx, sensorID, name string) *bbolt.Bucket {
+
+	sensor := tx.Bucket([]byte(sensorBucket)).Bucket([]byte(sensorID))
+	if sensor == nil {
+		return nil
+	}
+
+	return sensor.Bucket([]byte(name))
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+func (b *Backend) IterateSensors(fn func(sensorID string) error) error {
+
+	return b.db.View(func(tx *bbolt.Tx) error {
+
+		root := tx.Bucket([]byte(sensorBucket))
+
+		return root.ForEach(func(k, v []byte) error {
+			if v != nil {
+				return nil
+			}
+			return fn(string(k))
+		})
+	})
+}
+
+func convertToValues(k []byte, v []byte) (*sensorstore.TimedSensorValues, error) {
+
+	timestamp, err := time.Parse(timeFormat, string(k))
+	if err != nil {
+		return nil, err
+	}
+
+	values := sensorstore.SensorValues{}
+	err = json.Unmarshal(v, &values)
+	if err != nil {
+		return nil, err
+	}
+
+	result := sensorstore.TimedSensorValues{Timestamp: timestamp, Values: values}
+
+	return &result, nil
+}
@@ -0,0 +1,141 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package bolt
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	bbolt "go.etcd.io/bbolt"
+)
+
+func newTestBackend(t *testing.T, resolution time.Duration) *Backend {
+	t.Helper()
+
+	db, err := bbolt.Open(filepath.Join(t.TempDir(), "test.db"), 0600, &bbolt.Options{Timeout: time.Second})
+	if err != nil {
+		t.Fatalf("bbolt.Open: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	err = db.Update(func(tx *bbolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists([]byte(sensorBucket))
+		return err
+	})
+	if err != nil {
+		t.Fatalf("create sensor bucket: %s", err)
+	}
+
+	return &Backend{db: db, resolution: resolution}
+}
+
+func TestStoreAndReadValuesInLocalTimeZone(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata not available: %s", err)
+	}
+
+	b := newTestBackend(t, time.Minute)
+	now := time.Now().In(loc)
+
+	if err := b.StoreValues("cellar-top", now, sensorstore.SensorValues{Temperature: 12, Humidity: 55}); err != nil {
+		t.Fatalf("StoreValues: %s", err)
+	}
+
+	values, err := b.ReadValues("cellar-top", -time.Hour)
+	if err != nil {
+		t.Fatalf("ReadValues: %s", err)
+	}
+
+	if len(values) != 1 {
+		t.Fatalf("ReadValues returned %d values, want 1", len(values))
+	}
+
+	if values[0].Values.Temperature != 12 {
+		t.Errorf("Temperature = %v, want 12", values[0].Values.Temperature)
+	}
+}
+
+func TestStoreAndReadAggregatedInLocalTimeZone(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata not available: %s", err)
+	}
+
+	b := newTestBackend(t, time.Minute)
+	now := time.Now().In(loc)
+
+	agg := sensorstore.AggregatedSensorValues{MinTemperature: 10, AvgTemperature: 12, MaxTemperature: 14}
+	if err := b.StoreAggregated("cellar-top", sensorstore.ResolutionHourly, now, agg); err != nil {
+		t.Fatalf("StoreAggregated: %s", err)
+	}
+
+	values, err := b.ReadAggregated("cellar-top", sensorstore.ResolutionHourly, -time.Hour)
+	if err != nil {
+		t.Fatalf("ReadAggregated: %s", err)
+	}
+
+	if len(values) != 1 {
+		t.Fatalf("ReadAggregated returned %d values, want 1", len(values))
+	}
+
+	if values[0].Values != agg {
+		t.Errorf("Values = %+v, want %+v", values[0].Values, agg)
+	}
+}
+
+func TestDeleteBeforePrunesOnlyOlderRawSamples(t *testing.T) {
+
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("tzdata not available: %s", err)
+	}
+
+	b := newTestBackend(t, time.Minute)
+	now := time.Now().In(loc)
+
+	old := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Minute)
+
+	if err := b.StoreValues("cellar-top", old, sensorstore.SensorValues{Temperature: 1}); err != nil {
+		t.Fatalf("StoreValues(old): %s", err)
+	}
+	if err := b.StoreValues("cellar-top", recent, sensorstore.SensorValues{Temperature: 2}); err != nil {
+		t.Fatalf("StoreValues(recent): %s", err)
+	}
+
+	if err := b.DeleteBefore("cellar-top", sensorstore.ResolutionRaw, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("DeleteBefore: %s", err)
+	}
+
+	values, err := b.ReadValues("cellar-top", -24*time.Hour)
+	if err != nil {
+		t.Fatalf("ReadValues: %s", err)
+	}
+
+	if len(values) != 1 {
+		t.Fatalf("ReadValues returned %d values after DeleteBefore, want 1", len(values))
+	}
+
+	if values[0].Values.Temperature != 2 {
+		t.Errorf("surviving sample has Temperature %v, want 2 (the recent one)", values[0].Values.Temperature)
+	}
+}
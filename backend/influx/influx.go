@@ -0,0 +1,283 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package influx is the sensorstore.Backend implementation backing the
+// "influx://" URL scheme. Every sample is written as a point in the
+// "sensor_values" measurement, tagged by sensor ID, so the data can be
+// queried directly with Flux for time-series analytics.
+package influx
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api"
+)
+
+const measurement = "sensor_values"
+
+// aggregatedMeasurement returns the measurement a resolution's rolled-up
+// aggregates are written to, e.g. "sensor_values_hourly".
+func aggregatedMeasurement(resolution sensorstore.Resolution) string {
+	return measurement + "_" + string(resolution)
+}
+
+func init() {
+	sensorstore.RegisterBackend("influx", open)
+}
+
+// Backend stores sensor values as points in an InfluxDB bucket, one
+// point per sample truncated to resolution, tagged by sensor ID with
+// temperature and humidity as fields.
+type Backend struct {
+	client     influxdb2.Client
+	writeAPI   api.WriteAPIBlocking
+	queryAPI   api.QueryAPI
+	deleteAPI  api.DeleteAPI
+	org        string
+	bucket     string
+	resolution time.Duration
+}
+
+// open parses a URL of the form "influx://host:8086/bucket?org=...&token=..."
+func open(backendURL *url.URL, resolution time.Duration) (sensorstore.Backend, error) {
+
+	bucket := strings.TrimPrefix(backendURL.Path, "/")
+	if bucket == "" {
+		return nil, fmt.Errorf("influx backend URL must include a bucket, e.g. influx://host:8086/mybucket")
+	}
+
+	query := backendURL.Query()
+	org := query.Get("org")
+	token := query.Get("token")
+
+	addr := fmt.Sprintf("http://%s", backendURL.Host)
+
+	client := influxdb2.NewClient(addr, token)
+
+	return &Backend{
+		client:     client,
+		writeAPI:   client.WriteAPIBlocking(org, bucket),
+		queryAPI:   client.QueryAPI(org),
+		deleteAPI:  client.DeleteAPI(),
+		org:        org,
+		bucket:     bucket,
+		resolution: resolution,
+	}, nil
+}
+
+func (b *Backend) StoreValues(sensorID string, timestamp time.Time, values sensorstore.SensorValues) error {
+
+	point := influxdb2.NewPoint(
+		measurement,
+		map[string]string{"sensor": sensorID},
+		map[string]interface{}{
+			"temperature": values.Temperature,
+			"humidity":    values.Humidity,
+		},
+		timestamp.Truncate(b.resolution),
+	)
+
+	return b.writeAPI.WritePoint(context.Background(), point)
+}
+
+func (b *Backend) ReadLastValue(sensorID string) (*sensorstore.TimedSensorValues, error) {
+
+	query := fmt.Sprintf(`
+		from(bucket: %q)
+			|> range(start: -30d)
+			|> filter(fn: (r) => r._measurement == %q and r.sensor == %q)
+			|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> last()`, b.bucket, measurement, sensorID)
+
+	values, err := b.queryValues(query)
+	if err != nil || len(values) == 0 {
+		return nil, err
+	}
+
+	return values[len(values)-1], nil
+}
+
+func (b *Backend) ReadValues(sensorID string, duration time.Duration) ([]*sensorstore.TimedSensorValues, error) {
+
+	start, stop := "-"+duration.String(), "now()"
+	if duration < 0 {
+		start, stop = duration.String(), "now()"
+	}
+
+	query := fmt.Sprintf(`
+		from(bucket: %q)
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == %q and r.sensor == %q)
+			|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])`, b.bucket, start, stop, measurement, sensorID)
+
+	return b.queryValues(query)
+}
+
+// StoreAggregated persists a rolled-up aggregate as a point in the
+// resolution's aggregated measurement, tagged by sensor ID with min,
+// avg and max fields for both temperature and humidity.
+func (b *Backend) StoreAggregated(sensorID string, resolution sensorstore.Resolution, timestamp time.Time, values sensorstore.AggregatedSensorValues) error {
+
+	point := influxdb2.NewPoint(
+		aggregatedMeasurement(resolution),
+		map[string]string{"sensor": sensorID},
+		map[string]interface{}{
+			"min_temperature": values.MinTemperature,
+			"avg_temperature": values.AvgTemperature,
+			"max_temperature": values.MaxTemperature,
+			"min_humidity":    values.MinHumidity,
+			"avg_humidity":    values.AvgHumidity,
+			"max_humidity":    values.MaxHumidity,
+		},
+		timestamp,
+	)
+
+	return b.writeAPI.WritePoint(context.Background(), point)
+}
+
+// ReadAggregated returns every aggregate stored for sensorID at the
+// given resolution within duration of now, oldest first.
+func (b *Backend) ReadAggregated(sensorID string, resolution sensorstore.Resolution, duration time.Duration) ([]*sensorstore.TimedAggregatedSensorValues, error) {
+
+	start, stop := "-"+duration.String(), "now()"
+	if duration < 0 {
+		start, stop = duration.String(), "now()"
+	}
+
+	query := fmt.Sprintf(`
+		from(bucket: %q)
+			|> range(start: %s, stop: %s)
+			|> filter(fn: (r) => r._measurement == %q and r.sensor == %q)
+			|> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+			|> sort(columns: ["_time"])`, b.bucket, start, stop, aggregatedMeasurement(resolution), sensorID)
+
+	result, err := b.queryAPI.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	valuesList := []*sensorstore.TimedAggregatedSensorValues{}
+
+	for result.Next() {
+
+		record := result.Record()
+
+		minTemperature, _ := record.ValueByKey("min_temperature").(float64)
+		avgTemperature, _ := record.ValueByKey("avg_temperature").(float64)
+		maxTemperature, _ := record.ValueByKey("max_temperature").(float64)
+		minHumidity, _ := record.ValueByKey("min_humidity").(float64)
+		avgHumidity, _ := record.ValueByKey("avg_humidity").(float64)
+		maxHumidity, _ := record.ValueByKey("max_humidity").(float64)
+
+		valuesList = append(valuesList, &sensorstore.TimedAggregatedSensorValues{
+			Timestamp: record.Time(),
+			Values: sensorstore.AggregatedSensorValues{
+				MinTemperature: float32(minTemperature),
+				AvgTemperature: float32(avgTemperature),
+				MaxTemperature: float32(maxTemperature),
+				MinHumidity:    float32(minHumidity),
+				AvgHumidity:    float32(avgHumidity),
+				MaxHumidity:    float32(maxHumidity),
+			},
+		})
+	}
+
+	return valuesList, result.Err()
+}
+
+// DeleteBefore removes every point stored for sensorID at the given
+// resolution older than before.
+func (b *Backend) DeleteBefore(sensorID string, resolution sensorstore.Resolution, before time.Time) error {
+
+	measurementName := measurement
+	if resolution != sensorstore.ResolutionRaw {
+		measurementName = aggregatedMeasurement(resolution)
+	}
+
+	predicate := fmt.Sprintf("_measurement=%q AND sensor=%q", measurementName, sensorID)
+
+	return b.deleteAPI.DeleteWithName(context.Background(), b.org, b.bucket, time.Unix(0, 0), before, predicate)
+}
+
+func (b *Backend) Close() error {
+	b.client.Close()
+	return nil
+}
+
+func (b *Backend) IterateSensors(fn func(sensorID string) error) error {
+
+	query := fmt.Sprintf(`
+		from(bucket: %q)
+			|> range(start: -30d)
+			|> filter(fn: (r) => r._measurement == %q)
+			|> keep(columns: ["sensor"])
+			|> distinct(column: "sensor")`, b.bucket, measurement)
+
+	result, err := b.queryAPI.Query(context.Background(), query)
+	if err != nil {
+		return err
+	}
+	defer result.Close()
+
+	for result.Next() {
+		sensorID, ok := result.Record().ValueByKey("sensor").(string)
+		if !ok {
+			continue
+		}
+		if err := fn(sensorID); err != nil {
+			return err
+		}
+	}
+
+	return result.Err()
+}
+
+func (b *Backend) queryValues(query string) ([]*sensorstore.TimedSensorValues, error) {
+
+	result, err := b.queryAPI.Query(context.Background(), query)
+	if err != nil {
+		return nil, err
+	}
+	defer result.Close()
+
+	valuesList := []*sensorstore.TimedSensorValues{}
+
+	for result.Next() {
+
+		record := result.Record()
+
+		temperature, _ := record.ValueByKey("temperature").(float64)
+		humidity, _ := record.ValueByKey("humidity").(float64)
+
+		valuesList = append(valuesList, &sensorstore.TimedSensorValues{
+			Timestamp: record.Time(),
+			Values: sensorstore.SensorValues{
+				Temperature: float32(temperature),
+				Humidity:    float32(humidity),
+			},
+		})
+	}
+
+	return valuesList, result.Err()
+}
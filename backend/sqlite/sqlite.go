@@ -0,0 +1,369 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package sqlite is the sensorstore.Backend implementation backing the
+// "sqlite://" URL scheme. Each sensor gets its own table, one row per
+// sample timestamp truncated to the resolution (a later sample in the
+// same window replaces the earlier one), with timestamp, temperature and
+// humidity columns.
+package sqlite
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+const (
+	timeFormat    = time.RFC3339
+	tablePrefix   = "sensor_"
+	listTablesSQL = `SELECT name FROM sqlite_master WHERE type = 'table' AND name LIKE 'sensor\_%' ESCAPE '\'`
+)
+
+var validSensorID = regexp.MustCompile(`^[a-zA-Z0-9_-]+$`)
+
+func init() {
+	sensorstore.RegisterBackend("sqlite", open)
+}
+
+// Backend stores sensor values in a SQLite database, one table per
+// sensor, each row holding the timestamp, temperature and humidity of a
+// single sample truncated to resolution.
+type Backend struct {
+	db         *sql.DB
+	resolution time.Duration
+}
+
+func open(backendURL *url.URL, resolution time.Duration) (sensorstore.Backend, error) {
+
+	path := backendURL.Path
+	if path == "" {
+		path = backendURL.Host
+	}
+
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database %s: %s", path, err)
+	}
+
+	return &Backend{db: db, resolution: resolution}, nil
+}
+
+func tableName(sensorID string) (string, error) {
+	if !validSensorID.MatchString(sensorID) {
+		return "", fmt.Errorf("invalid sensor ID %q", sensorID)
+	}
+	return tablePrefix + sensorID, nil
+}
+
+// aggregatedTableName returns the table an aggregated resolution for
+// sensorID is stored in, e.g. "sensor_cellar_hourly".
+func aggregatedTableName(sensorID string, resolution sensorstore.Resolution) (string, error) {
+
+	table, err := tableName(sensorID)
+	if err != nil {
+		return "", err
+	}
+
+	return table + "_" + string(resolution), nil
+}
+
+func (b *Backend) ensureTable(sensorID string) (string, error) {
+
+	table, err := tableName(sensorID)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = b.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		timestamp TEXT PRIMARY KEY,
+		temperature REAL NOT NULL,
+		humidity REAL NOT NULL
+	)`, table))
+	if err != nil {
+		return "", fmt.Errorf("failed to create table %s: %s", table, err)
+	}
+
+	return table, nil
+}
+
+func (b *Backend) StoreValues(sensorID string, timestamp time.Time, values sensorstore.SensorValues) error {
+
+	table, err := b.ensureTable(sensorID)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.Exec(
+		fmt.Sprintf(`INSERT OR REPLACE INTO %s (timestamp, temperature, humidity) VALUES (?, ?, ?)`, table),
+		timestamp.Truncate(b.resolution).Format(timeFormat), values.Temperature, values.Humidity)
+
+	return err
+}
+
+func (b *Backend) ReadLastValue(sensorID string) (*sensorstore.TimedSensorValues, error) {
+
+	table, err := tableName(sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	row := b.db.QueryRow(
+		fmt.Sprintf(`SELECT timestamp, temperature, humidity FROM %s ORDER BY timestamp DESC LIMIT 1`, table))
+
+	values, err := scanRow(row)
+	if err != nil && strings.Contains(err.Error(), "no such table") {
+		return nil, nil
+	}
+
+	return values, err
+}
+
+func (b *Backend) ReadValues(sensorID string, duration time.Duration) ([]*sensorstore.TimedSensorValues, error) {
+
+	table, err := tableName(sensorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var start time.Time
+
+	if duration > 0 {
+		start = now.Add(-duration)
+	} else {
+		start = now.Add(duration)
+	}
+
+	rows, err := b.db.Query(
+		fmt.Sprintf(`SELECT timestamp, temperature, humidity FROM %s WHERE timestamp >= ? ORDER BY timestamp ASC`, table),
+		start.Format(timeFormat))
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return []*sensorstore.TimedSensorValues{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	valuesList := []*sensorstore.TimedSensorValues{}
+
+	for rows.Next() {
+
+		timestamp, values, err := scanRowValues(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		valuesList = append(valuesList, &sensorstore.TimedSensorValues{Timestamp: timestamp, Values: values})
+	}
+
+	return valuesList, rows.Err()
+}
+
+func (b *Backend) ensureAggregatedTable(sensorID string, resolution sensorstore.Resolution) (string, error) {
+
+	table, err := aggregatedTableName(sensorID, resolution)
+	if err != nil {
+		return "", err
+	}
+
+	_, err = b.db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+		timestamp TEXT PRIMARY KEY,
+		min_temperature REAL NOT NULL,
+		avg_temperature REAL NOT NULL,
+		max_temperature REAL NOT NULL,
+		min_humidity REAL NOT NULL,
+		avg_humidity REAL NOT NULL,
+		max_humidity REAL NOT NULL
+	)`, table))
+	if err != nil {
+		return "", fmt.Errorf("failed to create table %s: %s", table, err)
+	}
+
+	return table, nil
+}
+
+// StoreAggregated persists a rolled-up aggregate in the
+// "sensor_<id>_<resolution>" table.
+func (b *Backend) StoreAggregated(sensorID string, resolution sensorstore.Resolution, timestamp time.Time, values sensorstore.AggregatedSensorValues) error {
+
+	table, err := b.ensureAggregatedTable(sensorID, resolution)
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.Exec(fmt.Sprintf(`INSERT OR REPLACE INTO %s
+		(timestamp, min_temperature, avg_temperature, max_temperature, min_humidity, avg_humidity, max_humidity)
+		VALUES (?, ?, ?, ?, ?, ?, ?)`, table),
+		timestamp.Format(timeFormat),
+		values.MinTemperature, values.AvgTemperature, values.MaxTemperature,
+		values.MinHumidity, values.AvgHumidity, values.MaxHumidity)
+
+	return err
+}
+
+// ReadAggregated returns every aggregate stored for sensorID at the
+// given resolution within duration of now, oldest first.
+func (b *Backend) ReadAggregated(sensorID string, resolution sensorstore.Resolution, duration time.Duration) ([]*sensorstore.TimedAggregatedSensorValues, error) {
+
+	table, err := aggregatedTableName(sensorID, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var start time.Time
+
+	if duration > 0 {
+		start = now.Add(-duration)
+	} else {
+		start = now.Add(duration)
+	}
+
+	rows, err := b.db.Query(
+		fmt.Sprintf(`SELECT timestamp, min_temperature, avg_temperature, max_temperature, min_humidity, avg_humidity, max_humidity
+			FROM %s WHERE timestamp >= ? ORDER BY timestamp ASC`, table),
+		start.Format(timeFormat))
+	if err != nil {
+		if strings.Contains(err.Error(), "no such table") {
+			return []*sensorstore.TimedAggregatedSensorValues{}, nil
+		}
+		return nil, err
+	}
+	defer rows.Close()
+
+	valuesList := []*sensorstore.TimedAggregatedSensorValues{}
+
+	for rows.Next() {
+
+		var formatted string
+		var values sensorstore.AggregatedSensorValues
+
+		if err := rows.Scan(&formatted,
+			&values.MinTemperature, &values.AvgTemperature, &values.MaxTemperature,
+			&values.MinHumidity, &values.AvgHumidity, &values.MaxHumidity); err != nil {
+			return nil, err
+		}
+
+		timestamp, err := time.Parse(timeFormat, formatted)
+		if err != nil {
+			return nil, err
+		}
+
+		valuesList = append(valuesList, &sensorstore.TimedAggregatedSensorValues{Timestamp: timestamp, Values: values})
+	}
+
+	return valuesList, rows.Err()
+}
+
+// DeleteBefore removes every value stored for sensorID at the given
+// resolution older than before.
+func (b *Backend) DeleteBefore(sensorID string, resolution sensorstore.Resolution, before time.Time) error {
+
+	var table string
+	var err error
+
+	if resolution == sensorstore.ResolutionRaw {
+		table, err = tableName(sensorID)
+	} else {
+		table, err = aggregatedTableName(sensorID, resolution)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = b.db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE timestamp < ?`, table), before.Format(timeFormat))
+	if err != nil && strings.Contains(err.Error(), "no such table") {
+		return nil
+	}
+
+	return err
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+func (b *Backend) IterateSensors(fn func(sensorID string) error) error {
+
+	rows, err := b.db.Query(listTablesSQL)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return err
+		}
+
+		sensorID := strings.TrimPrefix(table, tablePrefix)
+		if strings.HasSuffix(sensorID, "_"+string(sensorstore.ResolutionHourly)) ||
+			strings.HasSuffix(sensorID, "_"+string(sensorstore.ResolutionDaily)) {
+			// An aggregate table, see aggregatedTableName, not a sensor.
+			continue
+		}
+
+		if err := fn(sensorID); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+type row interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanRow(r row) (*sensorstore.TimedSensorValues, error) {
+
+	timestamp, values, err := scanRowValues(r)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	return &sensorstore.TimedSensorValues{Timestamp: timestamp, Values: values}, nil
+}
+
+func scanRowValues(r row) (time.Time, sensorstore.SensorValues, error) {
+
+	var formatted string
+	var values sensorstore.SensorValues
+
+	if err := r.Scan(&formatted, &values.Temperature, &values.Humidity); err != nil {
+		return time.Time{}, values, err
+	}
+
+	timestamp, err := time.Parse(timeFormat, formatted)
+	if err != nil {
+		return time.Time{}, values, err
+	}
+
+	return timestamp, values, nil
+}
@@ -0,0 +1,120 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sqlite
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func newTestBackend(t *testing.T, resolution time.Duration) *Backend {
+	t.Helper()
+
+	db, err := sql.Open("sqlite3", filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("sql.Open: %s", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return &Backend{db: db, resolution: resolution}
+}
+
+func TestStoreValuesCoalescesSamplesWithinResolution(t *testing.T) {
+
+	b := newTestBackend(t, time.Hour)
+	windowStart := time.Now().Truncate(time.Hour)
+
+	if err := b.StoreValues("cellar_top", windowStart.Add(5*time.Minute), sensorstore.SensorValues{Temperature: 10}); err != nil {
+		t.Fatalf("StoreValues: %s", err)
+	}
+	if err := b.StoreValues("cellar_top", windowStart.Add(40*time.Minute), sensorstore.SensorValues{Temperature: 12}); err != nil {
+		t.Fatalf("StoreValues: %s", err)
+	}
+
+	values, err := b.ReadValues("cellar_top", -24*time.Hour)
+	if err != nil {
+		t.Fatalf("ReadValues: %s", err)
+	}
+
+	if len(values) != 1 {
+		t.Fatalf("ReadValues returned %d rows for two samples in the same %s window, want 1", len(values), time.Hour)
+	}
+
+	if values[0].Values.Temperature != 12 {
+		t.Errorf("Temperature = %v, want 12 (the later sample in the window)", values[0].Values.Temperature)
+	}
+}
+
+func TestStoreValuesKeepsSamplesInDifferentWindowsSeparate(t *testing.T) {
+
+	b := newTestBackend(t, time.Hour)
+	windowStart := time.Now().Truncate(time.Hour)
+
+	if err := b.StoreValues("cellar_top", windowStart, sensorstore.SensorValues{Temperature: 10}); err != nil {
+		t.Fatalf("StoreValues: %s", err)
+	}
+	if err := b.StoreValues("cellar_top", windowStart.Add(time.Hour), sensorstore.SensorValues{Temperature: 12}); err != nil {
+		t.Fatalf("StoreValues: %s", err)
+	}
+
+	values, err := b.ReadValues("cellar_top", -24*time.Hour)
+	if err != nil {
+		t.Fatalf("ReadValues: %s", err)
+	}
+
+	if len(values) != 2 {
+		t.Fatalf("ReadValues returned %d rows for two samples an hour apart, want 2", len(values))
+	}
+}
+
+func TestDeleteBeforePrunesOnlyOlderSamples(t *testing.T) {
+
+	b := newTestBackend(t, time.Minute)
+	now := time.Now()
+
+	old := now.Add(-2 * time.Hour)
+	recent := now.Add(-time.Minute)
+
+	if err := b.StoreValues("cellar_top", old, sensorstore.SensorValues{Temperature: 1}); err != nil {
+		t.Fatalf("StoreValues(old): %s", err)
+	}
+	if err := b.StoreValues("cellar_top", recent, sensorstore.SensorValues{Temperature: 2}); err != nil {
+		t.Fatalf("StoreValues(recent): %s", err)
+	}
+
+	if err := b.DeleteBefore("cellar_top", sensorstore.ResolutionRaw, now.Add(-time.Hour)); err != nil {
+		t.Fatalf("DeleteBefore: %s", err)
+	}
+
+	values, err := b.ReadValues("cellar_top", -24*time.Hour)
+	if err != nil {
+		t.Fatalf("ReadValues: %s", err)
+	}
+
+	if len(values) != 1 {
+		t.Fatalf("ReadValues returned %d values after DeleteBefore, want 1", len(values))
+	}
+
+	if values[0].Values.Temperature != 2 {
+		t.Errorf("surviving sample has Temperature %v, want 2 (the recent one)", values[0].Values.Temperature)
+	}
+}
@@ -0,0 +1,129 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package config parses the TOML configuration file that describes the
+// cellarsense daemon: which storage backend to use, whether to serve the
+// HTTP API, and which sensors to poll.
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const defaultResolution = 10 * time.Minute
+const defaultInterval = 10 * time.Minute
+const defaultAlertStatePath = "cellarsense-alerts.db"
+const defaultConsecutive = 1
+
+// Config is the top level daemon configuration.
+type Config struct {
+	Backend    string          `toml:"backend"`
+	HTTP       string          `toml:"http"`
+	Metrics    bool            `toml:"metrics"`
+	MQTT       string          `toml:"mqtt"`
+	Resolution Duration        `toml:"resolution"`
+	Retention  RetentionConfig `toml:"retention"`
+	Alerts     AlertsConfig    `toml:"alerts"`
+	Sensors    []SensorConfig  `toml:"sensor"`
+}
+
+// RetentionConfig configures how long the Store keeps raw samples and
+// hourly aggregates before rolling them up and discarding them. A zero
+// duration means keep that level forever.
+type RetentionConfig struct {
+	Raw    Duration `toml:"raw"`
+	Hourly Duration `toml:"hourly"`
+}
+
+// AlertsConfig configures the alerts.Engine: where it persists alert
+// state, which rules to evaluate and which notifier URLs to dispatch
+// firing and resolved Events to.
+type AlertsConfig struct {
+	StatePath string            `toml:"state"`
+	Rules     []AlertRuleConfig `toml:"rule"`
+	Notifiers []string          `toml:"notifiers"`
+}
+
+// AlertRuleConfig describes a single alerts.Rule. Min and Max are
+// pointers so a rule can leave either bound unchecked, e.g. a
+// humidity-too-high rule only sets Max.
+type AlertRuleConfig struct {
+	ID          string   `toml:"id"`
+	Sensor      string   `toml:"sensor"`
+	Metric      string   `toml:"metric"`
+	Min         *float32 `toml:"min"`
+	Max         *float32 `toml:"max"`
+	Consecutive int      `toml:"consecutive"`
+	Cooldown    Duration `toml:"cooldown"`
+}
+
+// SensorConfig describes a single sensor probe to poll.
+type SensorConfig struct {
+	ID       string   `toml:"id"`
+	Driver   string   `toml:"driver"`
+	Bus      int      `toml:"bus"`
+	Address  uint8    `toml:"address"`
+	Interval Duration `toml:"interval"`
+}
+
+// Duration wraps time.Duration so it can be parsed from TOML strings such
+// as "10m" or "1h30m" instead of a raw number of nanoseconds.
+type Duration time.Duration
+
+// UnmarshalText implements encoding.TextUnmarshaler for Duration.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %s", text, err)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// Load reads and parses the TOML configuration file at path, filling in
+// defaults for the resolution and any sensor that doesn't declare its own
+// sampling interval.
+func Load(path string) (*Config, error) {
+
+	var cfg Config
+	if _, err := toml.DecodeFile(path, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to load config %s: %s", path, err)
+	}
+
+	if cfg.Resolution == 0 {
+		cfg.Resolution = Duration(defaultResolution)
+	}
+
+	for i := range cfg.Sensors {
+		if cfg.Sensors[i].Interval == 0 {
+			cfg.Sensors[i].Interval = Duration(defaultInterval)
+		}
+	}
+
+	if cfg.Alerts.StatePath == "" {
+		cfg.Alerts.StatePath = defaultAlertStatePath
+	}
+
+	for i := range cfg.Alerts.Rules {
+		if cfg.Alerts.Rules[i].Consecutive == 0 {
+			cfg.Alerts.Rules[i].Consecutive = defaultConsecutive
+		}
+	}
+
+	return &cfg, nil
+}
@@ -0,0 +1,39 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package drivers
+
+import "github.com/oltoko/go-am2320"
+
+// am2320Sensor is the Sensor backed by an AM2320 probe. The AM2320 only
+// answers on a single fixed I2C address, so there is nothing to
+// configure besides the default.
+type am2320Sensor struct {
+	sensor am2320.Sensor
+}
+
+func newAM2320() Sensor {
+	return &am2320Sensor{sensor: am2320.Create(am2320.DefaultI2CAddr)}
+}
+
+func (s *am2320Sensor) Read() (Values, error) {
+
+	values, err := s.sensor.Read()
+	if err != nil {
+		return Values{}, err
+	}
+
+	return Values{Temperature: values.Temperature, Humidity: values.Humidity}, nil
+}
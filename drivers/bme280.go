@@ -0,0 +1,60 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package drivers
+
+import (
+	i2c "github.com/d2r2/go-i2c"
+
+	"github.com/d2r2/go-bsbmp"
+)
+
+// bme280Sensor is the Sensor backed by a BME280 probe on the given I2C
+// bus and address. Unlike the AM2320, the connection is opened fresh for
+// every read since the bus may be shared with other probes.
+type bme280Sensor struct {
+	bus     int
+	address uint8
+}
+
+func newBME280(bus int, address uint8) Sensor {
+	return &bme280Sensor{bus: bus, address: address}
+}
+
+func (s *bme280Sensor) Read() (Values, error) {
+
+	conn, err := i2c.NewI2C(s.address, s.bus)
+	if err != nil {
+		return Values{}, err
+	}
+	defer conn.Close()
+
+	sensor, err := bsbmp.NewBMP(bsbmp.BME280, conn)
+	if err != nil {
+		return Values{}, err
+	}
+
+	temperature, err := sensor.ReadTemperatureC(bsbmp.ACCURACY_STANDARD)
+	if err != nil {
+		return Values{}, err
+	}
+
+	_, humidity, err := sensor.ReadHumidityRH(bsbmp.ACCURACY_STANDARD)
+	if err != nil {
+		return Values{}, err
+	}
+
+	return Values{Temperature: temperature, Humidity: humidity}, nil
+}
@@ -0,0 +1,42 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package drivers
+
+import "github.com/d2r2/go-dht"
+
+// dht22Retries is how many times a failed one-wire read is retried
+// before giving up, as recommended by the go-dht documentation.
+const dht22Retries = 10
+
+// dht22Sensor is the Sensor backed by a DHT22 probe on the given GPIO
+// pin, read over the one-wire protocol.
+type dht22Sensor struct {
+	pin int
+}
+
+func newDHT22(pin int) Sensor {
+	return &dht22Sensor{pin: pin}
+}
+
+func (s *dht22Sensor) Read() (Values, error) {
+
+	humidity, temperature, _, err := dht.ReadDHTxxWithRetry(dht.DHT22, s.pin, false, dht22Retries)
+	if err != nil {
+		return Values{}, err
+	}
+
+	return Values{Temperature: temperature, Humidity: humidity}, nil
+}
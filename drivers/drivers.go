@@ -0,0 +1,49 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package drivers wraps the individual sensor libraries behind one
+// uniform Sensor interface so the daemon can poll any of them the same
+// way, selected at runtime by a config.SensorConfig's driver name.
+package drivers
+
+import "fmt"
+
+// Values is a single temperature/humidity reading from a Sensor.
+type Values struct {
+	Temperature, Humidity float32
+}
+
+// Sensor reads temperature and humidity from a physical, or mock, probe.
+type Sensor interface {
+	Read() (Values, error)
+}
+
+// New creates the Sensor for the given driver name ("am2320", "dht22",
+// "bme280" or "mock"). bus and address are only used by the drivers that
+// need them, e.g. bme280's I2C bus and address.
+func New(driver string, bus int, address uint8) (Sensor, error) {
+	switch driver {
+	case "am2320":
+		return newAM2320(), nil
+	case "dht22":
+		return newDHT22(bus), nil
+	case "bme280":
+		return newBME280(bus, address), nil
+	case "mock":
+		return newMock(), nil
+	default:
+		return nil, fmt.Errorf("unknown sensor driver %q", driver)
+	}
+}
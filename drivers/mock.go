@@ -0,0 +1,40 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package drivers
+
+import "math/rand"
+
+// mockBaseTemperature and mockBaseHumidity center the mock driver's
+// readings roughly around a typical wine cellar.
+const (
+	mockBaseTemperature = 13.0
+	mockBaseHumidity    = 65.0
+)
+
+// mockSensor is a Sensor that invents plausible readings instead of
+// talking to hardware, for development and testing without a probe.
+type mockSensor struct{}
+
+func newMock() Sensor {
+	return &mockSensor{}
+}
+
+func (s *mockSensor) Read() (Values, error) {
+	return Values{
+		Temperature: mockBaseTemperature + rand.Float32()*2 - 1,
+		Humidity:    mockBaseHumidity + rand.Float32()*10 - 5,
+	}, nil
+}
@@ -0,0 +1,197 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package httpapi exposes a sensorstore.Store over HTTP: JSON endpoints
+// for the latest value, historic values and the list of known sensors,
+// plus a Server-Sent-Events endpoint that streams newly stored values.
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+)
+
+const defaultValuesDuration = 24 * time.Hour
+
+// Server exposes a sensorstore.Store over HTTP.
+type Server struct {
+	store *sensorstore.Store
+}
+
+// New creates a Server that serves data from the given Store.
+func New(store *sensorstore.Store) *Server {
+	return &Server{store: store}
+}
+
+// Handler returns the http.Handler serving all registered endpoints:
+//
+//	GET /sensors                    list known sensor IDs
+//	GET /sensors/{id}/latest        the most recently stored value
+//	GET /sensors/{id}/values        historic values, ?duration=24h
+//	GET /sensors/{id}/aggregated    rolled-up values, ?resolution=hourly&duration=720h
+//	GET /sensors/{id}/stream        SSE stream of newly stored values
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sensors", s.handleListSensors)
+	mux.HandleFunc("/sensors/", s.handleSensor)
+	return mux
+}
+
+func (s *Server) handleListSensors(w http.ResponseWriter, r *http.Request) {
+
+	sensors, err := s.store.Sensors()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, sensors)
+}
+
+func (s *Server) handleSensor(w http.ResponseWriter, r *http.Request) {
+
+	path := strings.TrimPrefix(r.URL.Path, "/sensors/")
+	sensorID, action, found := strings.Cut(path, "/")
+	if !found || sensorID == "" || action == "" {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch action {
+	case "latest":
+		s.handleLatest(w, r, sensorID)
+	case "values":
+		s.handleValues(w, r, sensorID)
+	case "aggregated":
+		s.handleAggregated(w, r, sensorID)
+	case "stream":
+		s.handleStream(w, r, sensorID)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) handleLatest(w http.ResponseWriter, r *http.Request, sensorID string) {
+
+	value, err := s.store.ReadLastValue(sensorID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, value)
+}
+
+func (s *Server) handleValues(w http.ResponseWriter, r *http.Request, sensorID string) {
+
+	duration := defaultValuesDuration
+
+	if raw := r.URL.Query().Get("duration"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid duration %q: %s", raw, err), http.StatusBadRequest)
+			return
+		}
+		duration = parsed
+	}
+
+	values, err := s.store.ReadValues(sensorID, duration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, values)
+}
+
+func (s *Server) handleAggregated(w http.ResponseWriter, r *http.Request, sensorID string) {
+
+	resolution := sensorstore.Resolution(r.URL.Query().Get("resolution"))
+	if resolution != sensorstore.ResolutionHourly && resolution != sensorstore.ResolutionDaily {
+		http.Error(w, fmt.Sprintf("invalid resolution %q, expected %q or %q", resolution, sensorstore.ResolutionHourly, sensorstore.ResolutionDaily), http.StatusBadRequest)
+		return
+	}
+
+	duration := defaultValuesDuration
+
+	if raw := r.URL.Query().Get("duration"); raw != "" {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid duration %q: %s", raw, err), http.StatusBadRequest)
+			return
+		}
+		duration = parsed
+	}
+
+	values, err := s.store.ReadAggregated(sensorID, resolution, duration)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, values)
+}
+
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request, sensorID string) {
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming not supported", http.StatusInternalServerError)
+		return
+	}
+
+	c, cancel := s.store.Subscribe(sensorID)
+	defer cancel()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case values, open := <-c:
+			if !open {
+				return
+			}
+
+			payload, err := json.Marshal(values)
+			if err != nil {
+				log.Println("Failed to marshal streamed value for", sensorID, err)
+				continue
+			}
+
+			fmt.Fprintf(w, "data: %s\n\n", payload)
+			flusher.Flush()
+
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Println("Failed to write JSON response", err)
+	}
+}
@@ -15,56 +15,218 @@
 package main
 
 import (
+	"context"
+	"flag"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"github.com/oltoko/cellarsense-store/alerts"
+	"github.com/oltoko/cellarsense-store/config"
+	"github.com/oltoko/cellarsense-store/drivers"
+	"github.com/oltoko/cellarsense-store/httpapi"
+	"github.com/oltoko/cellarsense-store/metrics"
+	"github.com/oltoko/cellarsense-store/mqttbridge"
 	"github.com/oltoko/cellarsense-store/sensorstore"
-	"github.com/oltoko/go-am2320"
 
-	bolt "go.etcd.io/bbolt"
-)
-
-const (
-	dbName         = "cellarsense.db"
-	sensorID       = "test"
-	entries        = 100
-	timeFormat     = time.RFC3339
-	timeResolution = time.Minute * 10
+	_ "github.com/oltoko/cellarsense-store/alerts/notifier/mqtt"
+	_ "github.com/oltoko/cellarsense-store/alerts/notifier/smtp"
+	_ "github.com/oltoko/cellarsense-store/alerts/notifier/webhook"
+	_ "github.com/oltoko/cellarsense-store/backend/bolt"
+	_ "github.com/oltoko/cellarsense-store/backend/influx"
+	_ "github.com/oltoko/cellarsense-store/backend/sqlite"
 )
 
 func main() {
 
-	log.Println("Opening Database", dbName)
-	db, err := bolt.Open(dbName, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	configPath := flag.String("config", "cellarsense.toml", "path to the daemon configuration file")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
 	if err != nil {
 		log.Fatalln(err)
 	}
-	defer db.Close()
 
-	log.Println("Creating Store")
-	store, err := sensorstore.New(db, timeResolution)
+	log.Println("Creating Store with backend", cfg.Backend)
+	retention := sensorstore.RetentionPolicy{
+		Raw:    time.Duration(cfg.Retention.Raw),
+		Hourly: time.Duration(cfg.Retention.Hourly),
+	}
+	store, err := sensorstore.New(cfg.Backend, time.Duration(cfg.Resolution), retention)
 	if err != nil {
 		log.Fatalln(err)
 	}
+	defer store.Close()
 
-	c, err := store.StoreValuesChannel(sensorID)
-	if err != nil {
-		log.Fatalln(err)
+	if cfg.HTTP != "" {
+		log.Println("Serving HTTP API on", cfg.HTTP)
+
+		mux := http.NewServeMux()
+		mux.Handle("/", httpapi.New(store).Handler())
+
+		if cfg.Metrics {
+			log.Println("Exposing Prometheus metrics on", cfg.HTTP, "/metrics")
+			exporter := metrics.New(store)
+			exporter.Start(context.Background(), sensorIDs(cfg.Sensors))
+			mux.Handle("/metrics", exporter.Handler())
+		}
+
+		go func() {
+			if err := http.ListenAndServe(cfg.HTTP, mux); err != nil {
+				log.Fatalln("HTTP API failed", err)
+			}
+		}()
+	}
+
+	if cfg.MQTT != "" {
+		log.Println("Starting MQTT bridge to", cfg.MQTT)
+
+		bridge, err := mqttbridge.New(store, cfg.MQTT)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer bridge.Close()
+
+		bridge.Start(context.Background(), sensorIDs(cfg.Sensors))
+	}
+
+	if len(cfg.Alerts.Rules) > 0 {
+		log.Println("Starting alert engine with state", cfg.Alerts.StatePath)
+
+		rules, err := alertRules(cfg.Alerts.Rules)
+		if err != nil {
+			log.Fatalln(err)
+		}
+
+		engine, err := alerts.New(store, cfg.Alerts.StatePath, rules, cfg.Alerts.Notifiers)
+		if err != nil {
+			log.Fatalln(err)
+		}
+		defer engine.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		engine.Start(ctx)
 	}
 
-	ticker := time.NewTicker(timeResolution)
 	signals := make(chan os.Signal, 1)
-	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+
+	runSensors(store, *configPath, cfg.Sensors, signals)
+}
+
+// sensorIDs returns the ID of every configured sensor, in configuration
+// order, for subsystems such as metrics and mqttbridge that subscribe to
+// a fixed set of sensors up front rather than discovering them from the
+// Store.
+func sensorIDs(sensors []config.SensorConfig) []string {
+
+	ids := make([]string, len(sensors))
+	for i, sensor := range sensors {
+		ids[i] = sensor.ID
+	}
+
+	return ids
+}
+
+// alertRules converts the configured alert rules into alerts.Rule,
+// validating the metric name since config.Load has no reason to know
+// about the alerts package.
+func alertRules(configured []config.AlertRuleConfig) ([]alerts.Rule, error) {
+
+	rules := make([]alerts.Rule, 0, len(configured))
 
-	readSensorValues(c, ticker, signals)
+	for _, c := range configured {
+
+		var metric alerts.Metric
+		switch c.Metric {
+		case "temperature":
+			metric = alerts.MetricTemperature
+		case "humidity":
+			metric = alerts.MetricHumidity
+		default:
+			return nil, fmt.Errorf("alert rule %s: unknown metric %q, expected temperature or humidity", c.ID, c.Metric)
+		}
+
+		rules = append(rules, alerts.Rule{
+			ID:          c.ID,
+			SensorID:    c.Sensor,
+			Metric:      metric,
+			Min:         c.Min,
+			Max:         c.Max,
+			Consecutive: c.Consecutive,
+			Cooldown:    time.Duration(c.Cooldown),
+		})
+	}
+
+	return rules, nil
+}
+
+// runSensors starts one goroutine per configured sensor and keeps them
+// running until a shutdown signal arrives. On SIGHUP it stops every
+// sensor goroutine, reloads the sensor list from configPath and starts
+// it again, so a cellar's probe layout can change without a restart.
+func runSensors(store *sensorstore.Store, configPath string, sensors []config.SensorConfig, signals chan os.Signal) {
+
+	cancel := startSensors(store, sensors)
+
+	for s := range signals {
+
+		if s != syscall.SIGHUP {
+			log.Println("Received Signal", s, "shutting down!")
+			cancel()
+			return
+		}
+
+		log.Println("Received SIGHUP, reloading configuration from", configPath)
+
+		cfg, err := config.Load(configPath)
+		if err != nil {
+			log.Println("Failed to reload config, keeping existing sensors running:", err)
+			continue
+		}
+
+		cancel()
+		cancel = startSensors(store, cfg.Sensors)
+	}
+}
+
+// startSensors starts one goroutine per sensorConfig and returns the
+// cancel func that stops this generation of them, for runSensors to
+// call once it is replaced or the daemon is shutting down.
+func startSensors(store *sensorstore.Store, sensors []config.SensorConfig) context.CancelFunc {
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	for _, sensorConfig := range sensors {
+		go runSensor(ctx, store, sensorConfig)
+	}
+
+	return cancel
 }
 
-func readSensorValues(c chan sensorstore.SensorValues, ticker *time.Ticker, signals chan os.Signal) {
+func runSensor(ctx context.Context, store *sensorstore.Store, sensorConfig config.SensorConfig) {
 
-	sensor := am2320.Create(am2320.DefaultI2CAddr)
+	sensor, err := drivers.New(sensorConfig.Driver, sensorConfig.Bus, sensorConfig.Address)
+	if err != nil {
+		log.Println("Failed to set up sensor", sensorConfig.ID, err)
+		return
+	}
+
+	c, err := store.StoreValuesChannel(sensorConfig.ID)
+	if err != nil {
+		log.Println("Failed to open store channel for sensor", sensorConfig.ID, err)
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(sensorConfig.Interval))
+	defer ticker.Stop()
+
+	log.Println("Polling sensor", sensorConfig.ID, "with driver", sensorConfig.Driver, "every", time.Duration(sensorConfig.Interval))
 
 	for {
 		select {
@@ -72,13 +234,14 @@ func readSensorValues(c chan sensorstore.SensorValues, ticker *time.Ticker, sign
 
 			values, err := sensor.Read()
 			if err != nil {
-				log.Fatalln("Failed to read sensor values", err)
+				log.Println("Failed to read sensor values for", sensorConfig.ID, err)
+				continue
 			}
 
 			c <- sensorstore.SensorValues{Temperature: values.Temperature, Humidity: values.Humidity}
 
-		case s := <-signals:
-			log.Println("Received Signal", s, "shutting down!")
+		case <-ctx.Done():
+			close(c)
 			return
 		}
 	}
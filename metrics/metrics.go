@@ -0,0 +1,98 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package metrics exposes every sensor's latest values as Prometheus
+// gauges, updated from the same sensorstore.Store subscriptions the
+// httpapi SSE stream uses.
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Exporter keeps a cellarsense_temperature_celsius and
+// cellarsense_humidity_percent gauge, labelled by sensor, up to date.
+type Exporter struct {
+	store       *sensorstore.Store
+	registry    *prometheus.Registry
+	temperature *prometheus.GaugeVec
+	humidity    *prometheus.GaugeVec
+}
+
+// New creates an Exporter reporting values stored in store.
+func New(store *sensorstore.Store) *Exporter {
+
+	temperature := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cellarsense_temperature_celsius",
+		Help: "Latest temperature reading of a sensor, in degrees Celsius.",
+	}, []string{"sensor"})
+
+	humidity := prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "cellarsense_humidity_percent",
+		Help: "Latest relative humidity reading of a sensor, in percent.",
+	}, []string{"sensor"})
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(temperature, humidity)
+
+	return &Exporter{
+		store:       store,
+		registry:    registry,
+		temperature: temperature,
+		humidity:    humidity,
+	}
+}
+
+// Handler returns the http.Handler serving the Prometheus text exposition
+// format at whatever path the caller mounts it on, typically "/metrics".
+func (e *Exporter) Handler() http.Handler {
+	return promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+}
+
+// Start subscribes to every sensor in sensorIDs and keeps their gauges
+// updated with newly stored values until ctx is cancelled.
+func (e *Exporter) Start(ctx context.Context, sensorIDs []string) {
+	for _, sensorID := range sensorIDs {
+		go e.watch(ctx, sensorID)
+	}
+}
+
+func (e *Exporter) watch(ctx context.Context, sensorID string) {
+
+	c, cancel := e.store.Subscribe(sensorID)
+	defer cancel()
+
+	for {
+		select {
+		case values, open := <-c:
+
+			if !open {
+				return
+			}
+
+			e.temperature.WithLabelValues(sensorID).Set(float64(values.Values.Temperature))
+			e.humidity.WithLabelValues(sensorID).Set(float64(values.Values.Humidity))
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
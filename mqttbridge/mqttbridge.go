@@ -0,0 +1,140 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+// Package mqttbridge publishes every value a sensorstore.Store stores
+// to an MQTT broker, so the daemon integrates with Home Assistant and
+// similar ecosystems without them having to poll the HTTP API.
+package mqttbridge
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oltoko/cellarsense-store/sensorstore"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+)
+
+const connectTimeout = 10 * time.Second
+
+// Bridge subscribes to every configured sensor and republishes its
+// values to "<topicPrefix>/<sensor_id>/temperature" and
+// "<topicPrefix>/<sensor_id>/humidity".
+type Bridge struct {
+	store       *sensorstore.Store
+	client      paho.Client
+	topicPrefix string
+	qos         byte
+	retained    bool
+}
+
+// New parses a URL of the form
+// "mqtt://broker:1883/cellarsense?qos=1&retained=true" and connects to
+// broker, ready to publish values stored in store under the path as
+// topic prefix.
+func New(store *sensorstore.Store, brokerURL string) (*Bridge, error) {
+
+	parsed, err := url.Parse(brokerURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse mqtt broker URL %q: %s", brokerURL, err)
+	}
+
+	topicPrefix := strings.Trim(parsed.Path, "/")
+	if topicPrefix == "" {
+		topicPrefix = "cellarsense"
+	}
+
+	query := parsed.Query()
+
+	var qos byte
+	if raw := query.Get("qos"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 0 || parsed > 2 {
+			return nil, fmt.Errorf("invalid mqtt qos %q, must be 0, 1 or 2", raw)
+		}
+		qos = byte(parsed)
+	}
+
+	retained := query.Get("retained") == "true"
+
+	opts := paho.NewClientOptions().AddBroker(fmt.Sprintf("tcp://%s", parsed.Host))
+	if parsed.User != nil {
+		opts.SetUsername(parsed.User.Username())
+		if password, ok := parsed.User.Password(); ok {
+			opts.SetPassword(password)
+		}
+	}
+
+	client := paho.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(connectTimeout) {
+		return nil, fmt.Errorf("timed out connecting to mqtt broker %s", parsed.Host)
+	}
+	if err := token.Error(); err != nil {
+		return nil, fmt.Errorf("failed to connect to mqtt broker %s: %s", parsed.Host, err)
+	}
+
+	return &Bridge{
+		store:       store,
+		client:      client,
+		topicPrefix: topicPrefix,
+		qos:         qos,
+		retained:    retained,
+	}, nil
+}
+
+// Start subscribes to every sensor in sensorIDs and republishes newly
+// stored values until ctx is cancelled.
+func (b *Bridge) Start(ctx context.Context, sensorIDs []string) {
+	for _, sensorID := range sensorIDs {
+		go b.watch(ctx, sensorID)
+	}
+}
+
+func (b *Bridge) watch(ctx context.Context, sensorID string) {
+
+	c, cancel := b.store.Subscribe(sensorID)
+	defer cancel()
+
+	for {
+		select {
+		case values, open := <-c:
+
+			if !open {
+				return
+			}
+
+			b.publish(sensorID, "temperature", values.Values.Temperature)
+			b.publish(sensorID, "humidity", values.Values.Humidity)
+
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *Bridge) publish(sensorID, metric string, value float32) {
+	topic := fmt.Sprintf("%s/%s/%s", b.topicPrefix, sensorID, metric)
+	b.client.Publish(topic, b.qos, b.retained, strconv.FormatFloat(float64(value), 'f', 2, 32))
+}
+
+// Close disconnects from the MQTT broker.
+func (b *Bridge) Close() {
+	b.client.Disconnect(250)
+}
@@ -0,0 +1,72 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sensorstore
+
+import "time"
+
+// Resolution identifies a level of the rollup hierarchy a Store keeps
+// values at: raw samples, hourly aggregates or daily aggregates.
+type Resolution string
+
+const (
+	ResolutionRaw    Resolution = "raw"
+	ResolutionHourly Resolution = "hourly"
+	ResolutionDaily  Resolution = "daily"
+)
+
+// window returns the size of the aggregation window for the resolution,
+// i.e. the period that a single aggregate summarises.
+func (r Resolution) window() time.Duration {
+	switch r {
+	case ResolutionHourly:
+		return time.Hour
+	case ResolutionDaily:
+		return 24 * time.Hour
+	default:
+		return 0
+	}
+}
+
+// AggregatedSensorValues summarises the raw Temperature and Humidity
+// samples recorded during one aggregation window.
+type AggregatedSensorValues struct {
+	MinTemperature, AvgTemperature, MaxTemperature float32
+	MinHumidity, AvgHumidity, MaxHumidity          float32
+}
+
+// TimedAggregatedSensorValues pairs an AggregatedSensorValues with the
+// start of the window it summarises.
+type TimedAggregatedSensorValues struct {
+	Timestamp time.Time
+	Values    AggregatedSensorValues
+}
+
+// RetentionPolicy configures how long a Store keeps raw samples and
+// hourly aggregates before the compactor rolls them up and discards
+// them. Daily aggregates are kept forever. A zero duration means keep
+// that level forever too.
+type RetentionPolicy struct {
+	Raw    time.Duration
+	Hourly time.Duration
+}
+
+// ReadAggregated returns the aggregates stored for sensorID at the given
+// resolution within duration of now, oldest first, so callers such as a
+// long-range chart can render months of history without loading every
+// raw sample.
+func (s *Store) ReadAggregated(sensorID string, resolution Resolution, duration time.Duration) ([]*TimedAggregatedSensorValues, error) {
+	return s.backend.ReadAggregated(sensorID, resolution, duration)
+}
@@ -0,0 +1,92 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sensorstore
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Backend is the storage abstraction Store delegates persistence and
+// querying to. Implementations live in their own backend/<name> package,
+// e.g. backend/bolt or backend/sqlite, and register themselves with
+// RegisterBackend from an init function.
+type Backend interface {
+
+	// StoreValues persists values recorded at timestamp for sensorID.
+	StoreValues(sensorID string, timestamp time.Time, values SensorValues) error
+
+	// ReadLastValue returns the most recently stored value for sensorID,
+	// or nil if nothing has been stored for it yet.
+	ReadLastValue(sensorID string) (*TimedSensorValues, error)
+
+	// ReadValues returns every value stored for sensorID within duration
+	// of now, oldest first. The sign of duration is ignored: both -24h
+	// and 24h look backwards from now.
+	ReadValues(sensorID string, duration time.Duration) ([]*TimedSensorValues, error)
+
+	// IterateSensors calls fn once, in no particular order, for every
+	// sensor ID the backend currently holds values for.
+	IterateSensors(fn func(sensorID string) error) error
+
+	// StoreAggregated persists a rolled-up aggregate computed by the
+	// compactor for sensorID at the given resolution, keyed by the start
+	// of the aggregation window.
+	StoreAggregated(sensorID string, resolution Resolution, timestamp time.Time, values AggregatedSensorValues) error
+
+	// ReadAggregated returns every aggregate stored for sensorID at the
+	// given resolution within duration of now, oldest first.
+	ReadAggregated(sensorID string, resolution Resolution, duration time.Duration) ([]*TimedAggregatedSensorValues, error)
+
+	// DeleteBefore removes every value stored for sensorID at the given
+	// resolution older than before. It is used to enforce a Store's
+	// RetentionPolicy once raw samples or aggregates have aged out.
+	DeleteBefore(sensorID string, resolution Resolution, before time.Time) error
+
+	// Close releases any resources held by the backend, e.g. open file
+	// handles or network connections.
+	Close() error
+}
+
+// OpenFunc opens the Backend described by a backend URL, e.g.
+// "bolt:///cellar.db" or "sqlite:///cellar.db".
+type OpenFunc func(backendURL *url.URL, resolution time.Duration) (Backend, error)
+
+var backends = make(map[string]OpenFunc)
+
+// RegisterBackend makes a Backend available under the given backend URL
+// scheme. It is meant to be called from the init function of a
+// backend/<name> package that New can then dispatch to, once that
+// package has been imported (typically blank-imported) by main.
+func RegisterBackend(scheme string, open OpenFunc) {
+	backends[scheme] = open
+}
+
+func openBackend(rawURL string, resolution time.Duration) (Backend, error) {
+
+	parsed, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse backend URL %q: %s", rawURL, err)
+	}
+
+	open, ok := backends[parsed.Scheme]
+	if !ok {
+		return nil, fmt.Errorf("unknown backend %q, is its package imported?", parsed.Scheme)
+	}
+
+	return open(parsed, resolution)
+}
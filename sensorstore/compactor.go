@@ -0,0 +1,261 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sensorstore
+
+import (
+	"log"
+	"time"
+)
+
+// compactInterval is how often the compactor wakes up to roll raw
+// samples into hourly aggregates, hourly aggregates into daily ones, and
+// prune whatever has aged out of its RetentionPolicy.
+const compactInterval = time.Hour
+
+// fallbackScanWindow bounds how far back the compactor looks for a
+// resolution whose RetentionPolicy duration is zero, i.e. "keep
+// forever", so a single compaction pass still has a finite amount of
+// work to do.
+const fallbackScanWindow = 365 * 24 * time.Hour
+
+// compactorRoutine periodically rolls up and prunes every known sensor
+// until the Store is closed, at which point its Backend starts
+// returning errors and each pass simply logs and retries on the next
+// tick.
+func (s *Store) compactorRoutine() {
+
+	ticker := time.NewTicker(compactInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		s.compact()
+	}
+}
+
+func (s *Store) compact() {
+
+	sensors, err := s.Sensors()
+	if err != nil {
+		log.Println("Compactor failed to list sensors:", err)
+		return
+	}
+
+	for _, sensorID := range sensors {
+		if err := s.rollUp(sensorID); err != nil {
+			log.Println("Compactor failed to roll up", sensorID, ":", err)
+		}
+
+		if err := s.prune(sensorID); err != nil {
+			log.Println("Compactor failed to prune", sensorID, ":", err)
+		}
+	}
+}
+
+// rollUp computes hourly aggregates from raw samples and daily
+// aggregates from hourly ones, for every window that has fully elapsed.
+func (s *Store) rollUp(sensorID string) error {
+
+	if err := s.rollUpRaw(sensorID); err != nil {
+		return err
+	}
+
+	return s.rollUpAggregated(sensorID, ResolutionHourly, ResolutionDaily)
+}
+
+func (s *Store) rollUpRaw(sensorID string) error {
+
+	raw, err := s.backend.ReadValues(sensorID, -s.scanWindow(ResolutionRaw))
+	if err != nil {
+		return err
+	}
+
+	for windowStart, samples := range bucketRaw(raw, ResolutionHourly.window()) {
+
+		if !windowComplete(windowStart, ResolutionHourly.window()) {
+			continue
+		}
+
+		if err := s.backend.StoreAggregated(sensorID, ResolutionHourly, windowStart, aggregateRaw(samples)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) rollUpAggregated(sensorID string, from, to Resolution) error {
+
+	aggregates, err := s.backend.ReadAggregated(sensorID, from, -s.scanWindow(from))
+	if err != nil {
+		return err
+	}
+
+	for windowStart, values := range bucketAggregated(aggregates, to.window()) {
+
+		if !windowComplete(windowStart, to.window()) {
+			continue
+		}
+
+		if err := s.backend.StoreAggregated(sensorID, to, windowStart, aggregateAggregated(values)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) prune(sensorID string) error {
+
+	if s.retention.Raw > 0 {
+		if err := s.backend.DeleteBefore(sensorID, ResolutionRaw, time.Now().Add(-s.retention.Raw)); err != nil {
+			return err
+		}
+	}
+
+	if s.retention.Hourly > 0 {
+		if err := s.backend.DeleteBefore(sensorID, ResolutionHourly, time.Now().Add(-s.retention.Hourly)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanWindow returns how far back the compactor should look for values
+// at resolution when rolling it up into the next one, falling back to a
+// generous default when the RetentionPolicy keeps that resolution
+// forever.
+func (s *Store) scanWindow(resolution Resolution) time.Duration {
+
+	switch resolution {
+	case ResolutionRaw:
+		if s.retention.Raw > 0 {
+			return s.retention.Raw
+		}
+	case ResolutionHourly:
+		if s.retention.Hourly > 0 {
+			return s.retention.Hourly
+		}
+	}
+
+	return fallbackScanWindow
+}
+
+// windowComplete reports whether the aggregation window of the given
+// size starting at windowStart has fully elapsed, so the compactor never
+// emits an aggregate for a window that is still being written to.
+func windowComplete(windowStart time.Time, window time.Duration) bool {
+	return time.Now().After(windowStart.Add(window))
+}
+
+func bucketRaw(values []*TimedSensorValues, window time.Duration) map[time.Time][]SensorValues {
+
+	buckets := make(map[time.Time][]SensorValues)
+
+	for _, value := range values {
+		start := value.Timestamp.Truncate(window)
+		buckets[start] = append(buckets[start], value.Values)
+	}
+
+	return buckets
+}
+
+func bucketAggregated(values []*TimedAggregatedSensorValues, window time.Duration) map[time.Time][]AggregatedSensorValues {
+
+	buckets := make(map[time.Time][]AggregatedSensorValues)
+
+	for _, value := range values {
+		start := value.Timestamp.Truncate(window)
+		buckets[start] = append(buckets[start], value.Values)
+	}
+
+	return buckets
+}
+
+func aggregateRaw(samples []SensorValues) AggregatedSensorValues {
+
+	agg := AggregatedSensorValues{
+		MinTemperature: samples[0].Temperature,
+		MaxTemperature: samples[0].Temperature,
+		MinHumidity:    samples[0].Humidity,
+		MaxHumidity:    samples[0].Humidity,
+	}
+
+	var temperatureSum, humiditySum float32
+
+	for _, sample := range samples {
+
+		agg.MinTemperature = min32(agg.MinTemperature, sample.Temperature)
+		agg.MaxTemperature = max32(agg.MaxTemperature, sample.Temperature)
+		agg.MinHumidity = min32(agg.MinHumidity, sample.Humidity)
+		agg.MaxHumidity = max32(agg.MaxHumidity, sample.Humidity)
+
+		temperatureSum += sample.Temperature
+		humiditySum += sample.Humidity
+	}
+
+	agg.AvgTemperature = temperatureSum / float32(len(samples))
+	agg.AvgHumidity = humiditySum / float32(len(samples))
+
+	return agg
+}
+
+// aggregateAggregated rolls a set of finer-grained aggregates up into a
+// single coarser one. The average is an unweighted mean of the input
+// averages, which is an approximation when the underlying sample counts
+// differ, but matches the precision the rest of the aggregation pipeline
+// already works at.
+func aggregateAggregated(values []AggregatedSensorValues) AggregatedSensorValues {
+
+	agg := AggregatedSensorValues{
+		MinTemperature: values[0].MinTemperature,
+		MaxTemperature: values[0].MaxTemperature,
+		MinHumidity:    values[0].MinHumidity,
+		MaxHumidity:    values[0].MaxHumidity,
+	}
+
+	var temperatureSum, humiditySum float32
+
+	for _, value := range values {
+
+		agg.MinTemperature = min32(agg.MinTemperature, value.MinTemperature)
+		agg.MaxTemperature = max32(agg.MaxTemperature, value.MaxTemperature)
+		agg.MinHumidity = min32(agg.MinHumidity, value.MinHumidity)
+		agg.MaxHumidity = max32(agg.MaxHumidity, value.MaxHumidity)
+
+		temperatureSum += value.AvgTemperature
+		humiditySum += value.AvgHumidity
+	}
+
+	agg.AvgTemperature = temperatureSum / float32(len(values))
+	agg.AvgHumidity = humiditySum / float32(len(values))
+
+	return agg
+}
+
+func min32(a, b float32) float32 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func max32(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
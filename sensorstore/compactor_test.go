@@ -0,0 +1,127 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sensorstore
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAggregateRaw(t *testing.T) {
+
+	samples := []SensorValues{
+		{Temperature: 10, Humidity: 50},
+		{Temperature: 14, Humidity: 60},
+		{Temperature: 12, Humidity: 40},
+	}
+
+	got := aggregateRaw(samples)
+
+	want := AggregatedSensorValues{
+		MinTemperature: 10,
+		MaxTemperature: 14,
+		AvgTemperature: 12,
+		MinHumidity:    40,
+		MaxHumidity:    60,
+		AvgHumidity:    50,
+	}
+
+	if got != want {
+		t.Fatalf("aggregateRaw(%v) = %+v, want %+v", samples, got, want)
+	}
+}
+
+func TestAggregateRawSingleSample(t *testing.T) {
+
+	samples := []SensorValues{{Temperature: 18.5, Humidity: 72}}
+
+	got := aggregateRaw(samples)
+
+	want := AggregatedSensorValues{
+		MinTemperature: 18.5,
+		MaxTemperature: 18.5,
+		AvgTemperature: 18.5,
+		MinHumidity:    72,
+		MaxHumidity:    72,
+		AvgHumidity:    72,
+	}
+
+	if got != want {
+		t.Fatalf("aggregateRaw(%v) = %+v, want %+v", samples, got, want)
+	}
+}
+
+func TestAggregateAggregated(t *testing.T) {
+
+	values := []AggregatedSensorValues{
+		{MinTemperature: 8, MaxTemperature: 16, AvgTemperature: 12, MinHumidity: 45, MaxHumidity: 65, AvgHumidity: 55},
+		{MinTemperature: 9, MaxTemperature: 20, AvgTemperature: 14, MinHumidity: 40, MaxHumidity: 60, AvgHumidity: 50},
+	}
+
+	got := aggregateAggregated(values)
+
+	want := AggregatedSensorValues{
+		MinTemperature: 8,
+		MaxTemperature: 20,
+		AvgTemperature: 13,
+		MinHumidity:    40,
+		MaxHumidity:    65,
+		AvgHumidity:    52.5,
+	}
+
+	if got != want {
+		t.Fatalf("aggregateAggregated(%v) = %+v, want %+v", values, got, want)
+	}
+}
+
+func TestWindowComplete(t *testing.T) {
+
+	now := time.Now()
+
+	if windowComplete(now.Add(-time.Minute), time.Hour) {
+		t.Fatal("windowComplete reported a window that started a minute ago as complete for a 1h window")
+	}
+
+	if !windowComplete(now.Add(-2*time.Hour), time.Hour) {
+		t.Fatal("windowComplete reported a window that started 2h ago as incomplete for a 1h window")
+	}
+}
+
+func TestBucketRaw(t *testing.T) {
+
+	hour := time.Hour
+	base := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	values := []*TimedSensorValues{
+		{Timestamp: base.Add(10 * time.Minute), Values: SensorValues{Temperature: 10}},
+		{Timestamp: base.Add(40 * time.Minute), Values: SensorValues{Temperature: 12}},
+		{Timestamp: base.Add(70 * time.Minute), Values: SensorValues{Temperature: 14}},
+	}
+
+	buckets := bucketRaw(values, hour)
+
+	if len(buckets) != 2 {
+		t.Fatalf("bucketRaw produced %d buckets, want 2", len(buckets))
+	}
+
+	if got := len(buckets[base]); got != 2 {
+		t.Errorf("bucket %v has %d samples, want 2", base, got)
+	}
+
+	if got := len(buckets[base.Add(hour)]); got != 1 {
+		t.Errorf("bucket %v has %d samples, want 1", base.Add(hour), got)
+	}
+}
@@ -0,0 +1,68 @@
+// Licensed to the Apache Software Foundation (ASF) under one
+// or more contributor license agreements.  See the NOTICE file
+// distributed with this work for additional information
+// regarding copyright ownership.  The ASF licenses this file
+// to you under the Apache License, Version 2.0 (the
+// "License"); you may not use this file except in compliance
+// with the License.  You may obtain a copy of the License at
+//   http://www.apache.org/licenses/LICENSE-2.0
+// Unless required by applicable law or agreed to in writing,
+// software distributed under the License is distributed on an
+// "AS IS" BASIS, WITHOUT WARRANTIES OR CONDITIONS OF ANY
+// KIND, either express or implied.  See the License for the
+// specific language governing permissions and limitations
+// under the License.
+
+package sensorstore
+
+import "log"
+
+// subscriberBufferSize bounds how many values a slow subscriber can lag
+// behind before newly stored values are dropped for it.
+const subscriberBufferSize = 16
+
+// Subscribe registers the caller for every TimedSensorValues stored for
+// sensorID from this point on. The returned cancel func must be called
+// once the subscriber is done to release the subscription and close the
+// channel.
+func (s *Store) Subscribe(sensorID string) (<-chan TimedSensorValues, func()) {
+
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	c := make(chan TimedSensorValues, subscriberBufferSize)
+	s.subscribers[sensorID] = append(s.subscribers[sensorID], c)
+
+	cancel := func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+
+		subs := s.subscribers[sensorID]
+		for i, sub := range subs {
+			if sub == c {
+				s.subscribers[sensorID] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		close(c)
+	}
+
+	return c, cancel
+}
+
+// publish fans the given values out to every subscriber of sensorID.
+// Subscribers that cannot keep up simply miss values instead of blocking
+// the store.
+func (s *Store) publish(sensorID string, values TimedSensorValues) {
+
+	s.subMu.RLock()
+	defer s.subMu.RUnlock()
+
+	for _, c := range s.subscribers[sensorID] {
+		select {
+		case c <- values:
+		default:
+			log.Println("Dropping value for slow subscriber on", sensorID)
+		}
+	}
+}
@@ -16,23 +16,17 @@
 package sensorstore
 
 import (
-	"encoding/json"
-	"fmt"
 	"log"
+	"sync"
 	"time"
-
-	bolt "go.etcd.io/bbolt"
-)
-
-const (
-	sensorBucket = "sensors"
-	timeFormat   = time.RFC3339
 )
 
 type Store struct {
-	db         *bolt.DB
-	resolution time.Duration
-	rootBucket string
+	backend   Backend
+	retention RetentionPolicy
+
+	subMu       sync.RWMutex
+	subscribers map[string][]chan TimedSensorValues
 }
 
 type SensorValues struct {
@@ -44,44 +38,36 @@ type TimedSensorValues struct {
 	Values    SensorValues
 }
 
-func New(db *bolt.DB, resolution time.Duration) (*Store, error) {
-
-	log.Println("Initialize Sensor Store with bucket", sensorBucket)
-	err := db.Update(func(tx *bolt.Tx) error {
-
-		_, err := tx.CreateBucketIfNotExists([]byte(sensorBucket))
-
-		if err != nil {
-			return fmt.Errorf("failed to create bucket %s: %s", sensorBucket, err)
-		}
-		return nil
-	})
+// New opens the Backend described by backendURL (e.g. "bolt:///cellar.db",
+// "sqlite:///cellar.db" or "influx://localhost:8086/cellar") and returns a
+// Store backed by it. The backend package implementing the given URL
+// scheme must have been imported, typically blank-imported, so that its
+// init function has registered itself via RegisterBackend.
+//
+// retention configures the Store's background compactor, which rolls
+// raw samples up into hourly and daily aggregates and discards data that
+// has aged out. Pass a zero-value RetentionPolicy to keep everything
+// forever.
+func New(backendURL string, resolution time.Duration, retention RetentionPolicy) (*Store, error) {
+
+	backend, err := openBackend(backendURL, resolution)
 	if err != nil {
 		return nil, err
 	}
 
-	return &Store{db: db, resolution: resolution, rootBucket: sensorBucket}, nil
-}
-
-func (s *Store) StoreValuesChannel(sensorID string) (chan SensorValues, error) {
-
-	// create the Bucket for the specific sensorID
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	store := &Store{
+		backend:     backend,
+		retention:   retention,
+		subscribers: make(map[string][]chan TimedSensorValues),
+	}
 
-		root := tx.Bucket([]byte(s.rootBucket))
+	go store.compactorRoutine()
 
-		_, err := root.CreateBucketIfNotExists([]byte(sensorID))
+	return store, nil
+}
 
-		if err != nil {
-			return fmt.Errorf("failed to create bucket %s: %s", sensorID, err)
-		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
-	}
+func (s *Store) StoreValuesChannel(sensorID string) (chan SensorValues, error) {
 
-	// create the channel which can be used to store the values
 	c := make(chan SensorValues)
 	go s.storeValuesRoutine(c, sensorID)
 
@@ -107,106 +93,41 @@ func (s *Store) storeValuesRoutine(c chan SensorValues, sensorID string) {
 
 func (s *Store) StoreValues(sensorID string, values SensorValues) error {
 
-	err := s.db.Update(func(tx *bolt.Tx) error {
+	now := time.Now()
 
-		root := tx.Bucket([]byte(s.rootBucket))
+	if err := s.backend.StoreValues(sensorID, now, values); err != nil {
+		return err
+	}
 
-		b := root.Bucket([]byte(sensorID))
+	s.publish(sensorID, TimedSensorValues{Timestamp: now, Values: values})
 
-		now := time.Now()
-		key := []byte(now.Truncate(s.resolution).Format(timeFormat))
+	return nil
+}
 
-		if value, err := json.Marshal(values); err != nil {
-			return err
-		} else if err := b.Put(key, value); err != nil {
-			return err
-		}
+// Sensors returns the IDs of every sensor the backend currently holds
+// values for, i.e. every sensor that StoreValues has been called for at
+// least once.
+func (s *Store) Sensors() ([]string, error) {
+
+	var sensors []string
 
+	err := s.backend.IterateSensors(func(sensorID string) error {
+		sensors = append(sensors, sensorID)
 		return nil
 	})
 
-	return err
+	return sensors, err
 }
 
 func (s *Store) ReadLastValue(sensorID string) (*TimedSensorValues, error) {
-
-	var lastValue *TimedSensorValues
-
-	err := s.db.View(func(tx *bolt.Tx) error {
-
-		root := tx.Bucket([]byte(s.rootBucket))
-		c := root.Bucket([]byte(sensorID)).Cursor()
-
-		k, v := c.Last()
-
-		result, err := convertToValues(k, v)
-		lastValue = result
-
-		return err
-	})
-
-	return lastValue, err
+	return s.backend.ReadLastValue(sensorID)
 }
 
 func (s *Store) ReadValues(sensorID string, duration time.Duration) ([]*TimedSensorValues, error) {
-
-	now := time.Now()
-
-	endTime := now.Truncate(s.resolution)
-	var start []byte
-
-	if duration > 0 {
-		start = []byte(now.Add(-duration).Truncate(s.resolution).Format(timeFormat))
-	} else {
-		start = []byte(now.Add(duration).Truncate(s.resolution).Format(timeFormat))
-	}
-
-	valuesList := []*TimedSensorValues{}
-
-	err := s.db.View(func(tx *bolt.Tx) error {
-
-		root := tx.Bucket([]byte(s.rootBucket))
-		c := root.Bucket([]byte(sensorID)).Cursor()
-
-		for k, v := c.Seek(start); len(k) != 0; k, v = c.Next() {
-
-			currentKey, err := time.Parse(timeFormat, string(k))
-			if err != nil {
-				return err
-			}
-
-			if currentKey.After(endTime) {
-				break
-			}
-
-			values, err := convertToValues(k, v)
-			if err != nil {
-				return err
-			}
-
-			valuesList = append(valuesList, values)
-		}
-
-		return nil
-	})
-
-	return valuesList, err
+	return s.backend.ReadValues(sensorID, duration)
 }
 
-func convertToValues(k []byte, v []byte) (*TimedSensorValues, error) {
-
-	timestamp, err := time.Parse(timeFormat, string(k))
-	if err != nil {
-		return nil, err
-	}
-
-	values := SensorValues{}
-	err = json.Unmarshal(v, &values)
-	if err != nil {
-		return nil, err
-	}
-
-	result := TimedSensorValues{Timestamp: timestamp, Values: values}
-
-	return &result, nil
+// Close releases the resources held by the underlying Backend.
+func (s *Store) Close() error {
+	return s.backend.Close()
 }